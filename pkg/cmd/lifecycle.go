@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+	"github.com/spf13/cobra"
+)
+
+var alertID string
+
+var ackCmd = &cobra.Command{
+	Use:   "ack",
+	Short: "Acknowledge an alert",
+	Long:  `Acknowledge a previously sent alert by its ID using the provider's acknowledge API.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := provider.GetProvider(providerName, apiKey, endpoint, providerOptions()...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize provider: %v", err)
+		}
+
+		if err := p.AcknowledgeAlert(cmd.Context(), alertID); err != nil {
+			return fmt.Errorf("failed to acknowledge alert: %v", err)
+		}
+
+		fmt.Printf("Successfully acknowledged alert %s on %s\n", alertID, providerName)
+		return nil
+	},
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Resolve an alert",
+	Long:  `Resolve a previously sent alert by its ID using the provider's resolve or close API.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := provider.GetProvider(providerName, apiKey, endpoint, providerOptions()...)
+		if err != nil {
+			return fmt.Errorf("failed to initialize provider: %v", err)
+		}
+
+		if err := p.ResolveAlert(cmd.Context(), alertID); err != nil {
+			return fmt.Errorf("failed to resolve alert: %v", err)
+		}
+
+		fmt.Printf("Successfully resolved alert %s on %s\n", alertID, providerName)
+		return nil
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{ackCmd, resolveCmd} {
+		c.Flags().StringVar(&providerName, "provider", "", "Provider name (required): opsgenie, pagerduty, slack, teams")
+		c.Flags().StringVar(&apiKey, "api-key", "", "API key for the provider")
+		c.Flags().StringVar(&endpoint, "endpoint", "", "Custom endpoint URL (optional)")
+		c.Flags().StringVar(&alertID, "id", "", "ID of the alert (required)")
+		addRetryFlags(c)
+
+		c.MarkFlagRequired("provider")
+		c.MarkFlagRequired("id")
+	}
+}
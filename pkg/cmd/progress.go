@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/generator"
+)
+
+// ttyRefresh is how often the progress line is redrawn on a terminal
+const ttyRefresh = 500 * time.Millisecond
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress reports the progress of the generator's run on stderr until the
+// returned function is called: as a refreshing line on a terminal, otherwise as a log
+// line every logInterval
+func startProgress(gen *generator.Generator, logInterval time.Duration) (stop func()) {
+	tty := isTerminal(os.Stderr)
+	every := logInterval
+	if tty {
+		every = ttyRefresh
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				if tty {
+					// Clear the progress line before the summary is printed
+					fmt.Fprint(os.Stderr, "\r\033[K")
+				}
+				return
+			case <-ticker.C:
+				line := formatProgress(gen.Progress())
+				if tty {
+					fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+				} else {
+					fmt.Fprintln(os.Stderr, line)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// formatProgress formats a progress snapshot as a single line
+func formatProgress(p generator.Progress) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%v] sent %d, failed %d, in-flight %d, %.2f alerts/sec",
+		p.Elapsed.Round(time.Second), p.Sent, p.Failed, p.InFlight, p.Rate)
+	if p.P95 > 0 {
+		fmt.Fprintf(&b, ", p95 %v", p.P95.Round(time.Microsecond))
+	}
+	if p.ETA > 0 {
+		fmt.Fprintf(&b, ", ETA %v", p.ETA.Round(time.Second))
+	}
+	return b.String()
+}
@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
 )
 
@@ -12,14 +17,26 @@ like OpsGenie, PagerDuty, etc. It can send individual alerts or run
 predefined scenarios to generate high volumes of alerts.`,
 }
 
-// Execute runs the root command
+// Execute runs the root command. The first SIGINT or SIGTERM cancels the command's
+// context so it can stop gracefully, a second one kills the process.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
 	// Add subcommands
 	rootCmd.AddCommand(sendCmd)
+	rootCmd.AddCommand(ackCmd)
+	rootCmd.AddCommand(resolveCmd)
 	rootCmd.AddCommand(scenarioCmd)
+	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(versionCmd)
 }
@@ -1,55 +1,282 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/copydataai/fake-backend-alerts/pkg/generator"
+	"github.com/copydataai/fake-backend-alerts/pkg/metrics"
 	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+	"github.com/copydataai/fake-backend-alerts/pkg/report"
+	"github.com/copydataai/fake-backend-alerts/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
 var (
-	scenarioName   string
-	count          int
-	interval       int
-	concurrency    int
-	scenarioParams map[string]string
+	scenarioName     string
+	scenarioFile     string
+	count            int
+	interval         int
+	alertRate        float64
+	arrival          string
+	stages           string
+	runDuration      time.Duration
+	showHistogram    bool
+	showProgress     bool
+	progressInterval time.Duration
+	reportFormat     string
+	reportFile       string
+	thresholds       report.Thresholds
+	concurrency      int
+	maxInFlight      int
+	scenarioParams   map[string]string
+	metricsListen    string
+	traceExporter    string
+	otlpEndpoint     string
 )
 
+// errInterrupted is the error of a scenario run stopped by a signal
+var errInterrupted = errors.New("scenario interrupted")
+
 var scenarioCmd = &cobra.Command{
 	Use:   "scenario",
 	Short: "Run an alert scenario",
 	Long:  `Run a predefined alert scenario to generate multiple alerts for stress testing.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p, err := provider.GetProvider(providerName, apiKey, endpoint)
+		p, err := provider.GetProvider(providerName, apiKey, endpoint, providerOptions()...)
 		if err != nil {
 			return fmt.Errorf("failed to initialize provider: %v", err)
 		}
 
+		if reportFormat == "" && reportFile != "" {
+			reportFormat = report.FormatFromPath(reportFile)
+		}
+		if reportFormat != "" {
+			if err := report.ValidateFormat(reportFormat); err != nil {
+				return err
+			}
+		}
+
+		// Keep stdout clean for the report when it isn't written to a file
+		var out io.Writer = os.Stdout
+		if reportFormat != "" && reportFile == "" {
+			out = os.Stderr
+		}
+
+		if maxInFlight > 0 && arrival == "" {
+			return fmt.Errorf("--max-in-flight only applies to open-loop runs with --arrival")
+		}
+
+		var fileScenario *generator.ScenarioFile
+		if scenarioFile != "" {
+			if stages != "" || arrival != "" {
+				return fmt.Errorf("--stages and --arrival can't be used with --file, phases set their own rates")
+			}
+			fileScenario, err = generator.LoadScenarioFile(scenarioFile)
+			if err != nil {
+				return err
+			}
+			scenarioName = fileScenario.Name
+		}
+
+		if metricsListen != "" {
+			m := metrics.New()
+			stopMetrics, err := m.Serve(metricsListen)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+
+			fmt.Fprintf(out, "Serving Prometheus metrics on %s/metrics\n", metricsListen)
+			p = m.Wrap(p, scenarioName)
+		}
+
+		if traceExporter != "" {
+			shutdown, err := tracing.Setup(cmd.Context(), traceExporter, otlpEndpoint)
+			if err != nil {
+				return err
+			}
+			defer flushTraces(shutdown)
+
+			p = tracing.Wrap(p, scenarioName)
+		}
+
 		gen := generator.NewGenerator(p)
-		
-		fmt.Printf("Running scenario '%s' with %d alerts at %d ms intervals using %d concurrent workers\n", 
-			scenarioName, count, interval, concurrency)
-		
-		result, err := gen.RunScenario(cmd.Context(), scenarioName, generator.ScenarioOptions{
+
+		var loadProfile []generator.Stage
+		if stages != "" {
+			loadProfile, err = generator.ParseStages(stages)
+			if err != nil {
+				return err
+			}
+		}
+
+		if fileScenario != nil {
+			fmt.Fprintf(out, "Running scenario '%s' with %d phases from %s\n", scenarioName, len(fileScenario.Phases), scenarioFile)
+		} else if len(loadProfile) > 0 {
+			fmt.Fprintf(out, "Running scenario '%s' with a %d-stage load profile using %d concurrent workers\n",
+				scenarioName, len(loadProfile), concurrency)
+		} else if runDuration > 0 && alertRate > 0 {
+			fmt.Fprintf(out, "Running scenario '%s' for %v at %.2f alerts/sec using %d concurrent workers\n",
+				scenarioName, runDuration, alertRate, concurrency)
+		} else if runDuration > 0 {
+			fmt.Fprintf(out, "Running scenario '%s' for %v at %d ms intervals using %d concurrent workers\n",
+				scenarioName, runDuration, interval, concurrency)
+		} else if alertRate > 0 {
+			fmt.Fprintf(out, "Running scenario '%s' with %d alerts at %.2f alerts/sec using %d concurrent workers\n",
+				scenarioName, count, alertRate, concurrency)
+		} else {
+			fmt.Fprintf(out, "Running scenario '%s' with %d alerts at %d ms intervals using %d concurrent workers\n",
+				scenarioName, count, interval, concurrency)
+		}
+
+		opts := generator.ScenarioOptions{
 			Count:       count,
 			Interval:    interval,
+			Rate:        alertRate,
+			Arrival:     arrival,
 			Concurrency: concurrency,
+			MaxInFlight: maxInFlight,
 			Params:      scenarioParams,
-		})
-		
-		if err != nil {
-			return fmt.Errorf("scenario failed: %v", err)
+			Stages:      loadProfile,
+			Duration:    runDuration,
+		}
+
+		var stopProgress func()
+		if showProgress {
+			stopProgress = startProgress(gen, progressInterval)
+		}
+
+		startedAt := time.Now()
+		var result generator.ScenarioResult
+		var runErr error
+		if fileScenario != nil {
+			result, runErr = gen.Run(cmd.Context(), fileScenario.Scenario(), opts)
+		} else {
+			result, runErr = gen.RunScenario(cmd.Context(), scenarioName, opts)
+		}
+
+		if stopProgress != nil {
+			stopProgress()
+		}
+
+		// An interrupted run still reports its partial results
+		interrupted := errors.Is(runErr, context.Canceled)
+		if interrupted {
+			runErr = errInterrupted
+		}
+
+		var checks []report.Check
+		if runErr == nil {
+			checks = thresholds.Evaluate(result)
+		}
+
+		if reportFormat != "" {
+			r := report.New(scenarioName, p.Name(), opts, result, startedAt, checks, runErr)
+			if reportFile != "" {
+				err = report.WriteFile(reportFile, reportFormat, r)
+			} else {
+				err = report.Write(os.Stdout, reportFormat, r)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write report: %v", err)
+			}
+		}
+
+		if runErr != nil && !interrupted {
+			return fmt.Errorf("scenario failed: %v", runErr)
+		}
+
+		if interrupted {
+			fmt.Fprintf(out, "Scenario interrupted: %d alerts sent, %d failed, %d retries\n", result.Sent, result.Failed, result.Retries)
+		} else {
+			fmt.Fprintf(out, "Scenario complete: %d alerts sent, %d failed, %d retries\n", result.Sent, result.Failed, result.Retries)
+		}
+		fmt.Fprintf(out, "Duration: %v\n", result.Duration)
+		fmt.Fprintf(out, "Rate: %.2f alerts/sec\n", result.Rate)
+		if arrival != "" {
+			fmt.Fprintf(out, "Schedule lag: avg %v, max %v\n", result.AvgLag, result.MaxLag)
+			if result.Dropped > 0 {
+				fmt.Fprintf(out, "Dropped: %d arrivals with %d alerts in flight\n", result.Dropped, maxInFlight)
+			}
+		}
+		printLatency(out, result.Latency)
+		printErrors(out, result.Errors)
+
+		for _, c := range checks {
+			status := "PASS"
+			if !c.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(out, "%s %s: %s\n", status, c.Name, c.Message)
+		}
+
+		// The outcome was already reported, so the error is only printed by main
+		if interrupted {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return errInterrupted
+		}
+
+		if failed := report.Failed(checks); len(failed) > 0 {
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			return fmt.Errorf("%d of %d thresholds violated", len(failed), len(checks))
 		}
 
-		fmt.Printf("Scenario complete: %d alerts sent, %d failed\n", result.Sent, result.Failed)
-		fmt.Printf("Duration: %v\n", result.Duration)
-		fmt.Printf("Rate: %.2f alerts/sec\n", result.Rate)
-		
 		return nil
 	},
 }
 
+// flushTraces exports the spans still buffered when the command ends
+func flushTraces(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to flush traces: %v\n", err)
+	}
+}
+
+// printLatency prints latency percentiles and, if requested, the latency histogram
+func printLatency(out io.Writer, h *generator.Histogram) {
+	if h == nil || h.Count() == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "Latency: p50 %v, p90 %v, p99 %v, max %v\n",
+		h.Percentile(50), h.Percentile(90), h.Percentile(99), h.Max())
+
+	if !showHistogram {
+		return
+	}
+
+	fmt.Fprintln(out, "Latency histogram:")
+	for _, b := range h.Buckets() {
+		pct := float64(b.Count) / float64(h.Count()) * 100
+		fmt.Fprintf(out, "  %12v - %-12v %8d %6.2f%% %s\n", b.From, b.To, b.Count, pct, strings.Repeat("#", int(pct/2)))
+	}
+}
+
+// printErrors prints the breakdown of failed requests by error class
+func printErrors(out io.Writer, errs []generator.ErrorClass) {
+	if len(errs) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "Errors:")
+	for _, ec := range errs {
+		fmt.Fprintf(out, "  %s: %d\n", ec.Class, ec.Count)
+		for _, sample := range ec.Samples {
+			fmt.Fprintf(out, "    e.g. %s\n", sample)
+		}
+	}
+}
+
 var listScenariosCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available scenarios",
@@ -63,15 +290,37 @@ var listScenariosCmd = &cobra.Command{
 }
 
 func init() {
-	scenarioCmd.Flags().StringVar(&providerName, "provider", "", "Provider name (required): opsgenie, pagerduty")
+	scenarioCmd.Flags().StringVar(&providerName, "provider", "", "Provider name (required): opsgenie, pagerduty, slack, teams")
 	scenarioCmd.Flags().StringVar(&apiKey, "api-key", "", "API key for the provider")
 	scenarioCmd.Flags().StringVar(&endpoint, "endpoint", "", "Custom endpoint URL (optional)")
-	scenarioCmd.Flags().StringVar(&scenarioName, "name", "escalating", "Scenario name: escalating, random, mixed")
+	scenarioCmd.Flags().StringVar(&scenarioName, "name", "escalating", "Scenario name: escalating, random, burst, mixed, lifecycle, flapping")
+	scenarioCmd.Flags().StringVar(&scenarioFile, "file", "", "Run a scenario defined in a YAML or JSON file instead of --name")
 	scenarioCmd.Flags().IntVar(&count, "count", 100, "Number of alerts to generate")
+	scenarioCmd.Flags().DurationVar(&runDuration, "duration", 0, "Keep generating alerts until this much time has elapsed, overrides --count (e.g. 10m)")
 	scenarioCmd.Flags().IntVar(&interval, "interval", 100, "Interval between alerts in milliseconds")
+	scenarioCmd.Flags().Float64Var(&alertRate, "rate", 0, "Target rate in alerts/sec, overrides --interval (e.g. 2 for 120 alerts/min)")
+	scenarioCmd.Flags().StringVar(&arrival, "arrival", "", "Open-loop arrival process for random and mixed scenarios: constant, poisson (default: closed-loop workers)")
+	scenarioCmd.Flags().StringVar(&stages, "stages", "", "Load profile of duration:rate stages, ramped linearly, overrides --count, --rate and --interval (e.g. 30s:10,2m:10,10s:200,30s:0)")
 	scenarioCmd.Flags().IntVar(&concurrency, "concurrency", 10, "Number of concurrent alert generators")
-	
+	scenarioCmd.Flags().IntVar(&maxInFlight, "max-in-flight", 0, "Drop open-loop arrivals while this many alerts are in flight (default: unbounded)")
+	scenarioCmd.Flags().BoolVar(&showProgress, "progress", true, "Show progress on stderr while the scenario runs")
+	scenarioCmd.Flags().DurationVar(&progressInterval, "progress-interval", 10*time.Second, "Interval between progress lines when stderr is not a terminal")
+	scenarioCmd.Flags().BoolVar(&showHistogram, "histogram", false, "Print the latency histogram")
+	scenarioCmd.Flags().StringVar(&reportFormat, "report-format", "", "Write a report of the run: json, csv, junit (default: from --report-file extension)")
+	scenarioCmd.Flags().StringVar(&reportFile, "report-file", "", "File to write the report to (default: stdout)")
+	scenarioCmd.Flags().Float64Var(&thresholds.MaxFailureRate, "max-failure-rate", -1, "Fail if the ratio of failed alerts exceeds this value, e.g. 0.01, negative to disable")
+	scenarioCmd.Flags().DurationVar(&thresholds.MaxP99, "max-p99", 0, "Fail if the p99 latency exceeds this value, e.g. 500ms (default: disabled)")
+	scenarioCmd.Flags().Float64Var(&thresholds.MinRate, "min-rate", 0, "Fail if the rate of sent alerts per second is below this value (default: disabled)")
+	scenarioCmd.Flags().StringToStringVar(&scenarioParams, "param", nil, "Scenario parameters as key=value pairs (e.g. --param ack_delay=2000)")
+
+	scenarioCmd.Flags().StringVar(&metricsListen, "metrics-listen", "", "Address to serve Prometheus metrics on while the scenario runs, e.g. :9090 (default: disabled)")
+
+	scenarioCmd.Flags().StringVar(&traceExporter, "trace-exporter", "", "Trace every alert send with OpenTelemetry: otlp, stdout (default: disabled)")
+	scenarioCmd.Flags().StringVar(&otlpEndpoint, "otlp-endpoint", "", "OTLP HTTP collector URL for --trace-exporter otlp (default: $OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318)")
+
+	addRetryFlags(scenarioCmd)
+
 	scenarioCmd.MarkFlagRequired("provider")
-	
+
 	scenarioCmd.AddCommand(listScenariosCmd)
 }
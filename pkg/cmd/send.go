@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/copydataai/fake-backend-alerts/pkg/provider"
@@ -16,6 +17,10 @@ var (
 	message      string
 	source       string
 	priority     string
+
+	retries        int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
 )
 
 var sendCmd = &cobra.Command{
@@ -23,13 +28,18 @@ var sendCmd = &cobra.Command{
 	Short: "Send an alert to a provider",
 	Long:  `Send an individual alert to a specified provider like OpsGenie, PagerDuty, etc.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p, err := provider.GetProvider(providerName, apiKey, endpoint)
+		p, err := provider.GetProvider(providerName, apiKey, endpoint, providerOptions()...)
 		if err != nil {
 			return fmt.Errorf("failed to initialize provider: %v", err)
 		}
 
+		// The ID is also the dedup key, so sends must not share it by default
+		if alertID == "" {
+			alertID = fmt.Sprintf("alert-%d-%04x", time.Now().UnixNano(), rand.Intn(1<<16))
+		}
+
 		alert := provider.Alert{
-			ID:        fmt.Sprintf("alert-%d", time.Now().Unix()),
+			ID:        alertID,
 			Message:   message,
 			Severity:  severity,
 			Source:    source,
@@ -41,19 +51,40 @@ var sendCmd = &cobra.Command{
 			return fmt.Errorf("failed to send alert: %v", err)
 		}
 
-		fmt.Printf("Successfully sent alert to %s\n", providerName)
+		fmt.Printf("Successfully sent alert %s to %s\n", alert.ID, providerName)
 		return nil
 	},
 }
 
+// addRetryFlags registers the flags configuring provider retries on a command
+func addRetryFlags(c *cobra.Command) {
+	c.Flags().IntVar(&retries, "retries", 0, "Number of retries on 429, 5xx and network errors")
+	c.Flags().DurationVar(&retryBaseDelay, "retry-base-delay", provider.DefaultRetryPolicy.BaseDelay, "Initial backoff between retries")
+	c.Flags().DurationVar(&retryMaxDelay, "retry-max-delay", provider.DefaultRetryPolicy.MaxDelay, "Maximum backoff between retries")
+}
+
+// providerOptions returns the provider options set by the command flags
+func providerOptions() []provider.Option {
+	return []provider.Option{
+		provider.WithRetryPolicy(provider.RetryPolicy{
+			MaxRetries: retries,
+			BaseDelay:  retryBaseDelay,
+			MaxDelay:   retryMaxDelay,
+		}),
+	}
+}
+
 func init() {
-	sendCmd.Flags().StringVar(&providerName, "provider", "", "Provider name (required): opsgenie, pagerduty")
+	sendCmd.Flags().StringVar(&providerName, "provider", "", "Provider name (required): opsgenie, pagerduty, slack, teams")
 	sendCmd.Flags().StringVar(&apiKey, "api-key", "", "API key for the provider")
 	sendCmd.Flags().StringVar(&endpoint, "endpoint", "", "Custom endpoint URL (optional)")
 	sendCmd.Flags().StringVar(&severity, "severity", "warning", "Alert severity: critical, error, warning, info")
 	sendCmd.Flags().StringVar(&message, "message", "Test alert", "Alert message")
 	sendCmd.Flags().StringVar(&source, "source", "alertcli", "Alert source")
 	sendCmd.Flags().StringVar(&priority, "priority", "medium", "Alert priority: low, medium, high, critical")
+	sendCmd.Flags().StringVar(&alertID, "id", "", "Alert ID used to acknowledge or resolve it later (default: generated)")
+
+	addRetryFlags(sendCmd)
 
 	sendCmd.MarkFlagRequired("provider")
 }
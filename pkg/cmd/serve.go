@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/server"
+	"github.com/spf13/cobra"
+)
+
+var listenAddr string
+
+// shutdownTimeout bounds how long the server waits for in-flight requests on shutdown
+const shutdownTimeout = 10 * time.Second
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the fake alert backend",
+	Long: `Run a fake alert backend exposing PagerDuty- and OpsGenie-compatible 
+endpoints, so alerts can be sent to it with --endpoint instead of real vendors.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := &http.Server{
+			Addr:    listenAddr,
+			Handler: server.NewServer(),
+		}
+
+		// Stop accepting connections on interrupt and let in-flight requests finish
+		shutdownErr := make(chan error, 1)
+		go func() {
+			<-cmd.Context().Done()
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			shutdownErr <- srv.Shutdown(ctx)
+		}()
+
+		fmt.Printf("Fake alert backend listening on %s\n", listenAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %v", err)
+		}
+
+		if err := <-shutdownErr; err != nil {
+			return fmt.Errorf("server shutdown failed: %v", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&listenAddr, "listen", ":8080", "Address to listen on")
+}
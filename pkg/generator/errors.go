@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"syscall"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// maxErrorSamples is the number of distinct error messages kept per error class
+const maxErrorSamples = 3
+
+// ErrorClass summarizes the failed requests of one category
+type ErrorClass struct {
+	Class   string
+	Count   int
+	Samples []string
+}
+
+// ClassifyError returns the category of a failed provider request, such as http_429 or timeout
+func ClassifyError(err error) string {
+	var httpErr *provider.HTTPError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &httpErr):
+		if httpErr.StatusCode >= 500 {
+			return "http_5xx"
+		}
+		return fmt.Sprintf("http_%d", httpErr.StatusCode)
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "other"
+	}
+}
+
+// errorStats aggregates failed requests by error class
+type errorStats map[string]*ErrorClass
+
+// add records a failed request
+func (s errorStats) add(err error) {
+	class := ClassifyError(err)
+	ec, ok := s[class]
+	if !ok {
+		ec = &ErrorClass{Class: class}
+		s[class] = ec
+	}
+
+	ec.Count++
+	if len(ec.Samples) < maxErrorSamples {
+		msg := err.Error()
+		for _, sample := range ec.Samples {
+			if sample == msg {
+				return
+			}
+		}
+		ec.Samples = append(ec.Samples, msg)
+	}
+}
+
+// list returns a copy of the error classes, most frequent first
+func (s errorStats) list() []ErrorClass {
+	result := make([]ErrorClass, 0, len(s))
+	for _, ec := range s {
+		c := *ec
+		c.Samples = append([]string(nil), ec.Samples...)
+		result = append(result, c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Class < result[j].Class
+	})
+	return result
+}
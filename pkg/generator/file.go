@@ -0,0 +1,358 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// ScenarioFile is a declarative scenario definition loaded from YAML or JSON. A run
+// goes through the phases in order, generating alerts from the weighted templates.
+type ScenarioFile struct {
+	Name        string                 `yaml:"name" json:"name"`
+	Description string                 `yaml:"description" json:"description"`
+	Severities  map[string]int         `yaml:"severities" json:"severities"`
+	Priorities  map[string]int         `yaml:"priorities" json:"priorities"`
+	Phases      []Phase                `yaml:"phases" json:"phases"`
+	Templates   []AlertTemplate        `yaml:"templates" json:"templates"`
+	Details     map[string]interface{} `yaml:"details" json:"details"`
+
+	details map[string]interface{}
+}
+
+// Phase is a stage of a file scenario. It ends after Count alerts or once Duration has
+// elapsed, whichever comes first. Unset fields fall back to the scenario options.
+type Phase struct {
+	Name        string  `yaml:"name" json:"name"`
+	Count       int     `yaml:"count" json:"count"`
+	Duration    string  `yaml:"duration" json:"duration"`
+	Rate        float64 `yaml:"rate" json:"rate"`
+	Interval    int     `yaml:"interval" json:"interval"`
+	Concurrency int     `yaml:"concurrency" json:"concurrency"`
+
+	duration time.Duration
+}
+
+// AlertTemplate describes a kind of alert generated by a file scenario. Severity and
+// priority distributions are weights by value and default to the scenario ones. The
+// message, sources and string details are Go templates rendered with TemplateData.
+type AlertTemplate struct {
+	Weight     int                    `yaml:"weight" json:"weight"`
+	Message    string                 `yaml:"message" json:"message"`
+	Sources    []string               `yaml:"sources" json:"sources"`
+	Severities map[string]int         `yaml:"severities" json:"severities"`
+	Priorities map[string]int         `yaml:"priorities" json:"priorities"`
+	Details    map[string]interface{} `yaml:"details" json:"details"`
+
+	message *Template
+	sources []*Template
+	details map[string]interface{}
+}
+
+// LoadScenarioFile reads a scenario definition from a YAML or JSON file
+func LoadScenarioFile(path string) (*ScenarioFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %v", err)
+	}
+
+	f := &ScenarioFile{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, f)
+	} else {
+		err = yaml.Unmarshal(data, f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scenario file: %v", err)
+	}
+
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario file %s: %v", path, err)
+	}
+
+	return f, nil
+}
+
+// validate checks the definition and fills in defaults
+func (f *ScenarioFile) validate() error {
+	if len(f.Phases) == 0 {
+		return fmt.Errorf("at least one phase is required")
+	}
+	if len(f.Templates) == 0 {
+		return fmt.Errorf("at least one template is required")
+	}
+
+	for i := range f.Phases {
+		p := &f.Phases[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("phase-%d", i+1)
+		}
+		if p.Duration != "" {
+			d, err := time.ParseDuration(p.Duration)
+			if err != nil {
+				return fmt.Errorf("phase %s: invalid duration: %v", p.Name, err)
+			}
+			p.duration = d
+		}
+		if p.Count < 0 || p.Rate < 0 || p.Interval < 0 || p.Concurrency < 0 {
+			return fmt.Errorf("phase %s: count, rate, interval and concurrency can't be negative", p.Name)
+		}
+	}
+
+	var err error
+	if f.details, err = parseDetailTemplates(f.Details); err != nil {
+		return err
+	}
+
+	for i := range f.Templates {
+		t := &f.Templates[i]
+		if t.Message == "" {
+			return fmt.Errorf("template %d: message is required", i+1)
+		}
+		if t.Weight < 0 {
+			return fmt.Errorf("template %d: weight can't be negative", i+1)
+		}
+		if t.Weight == 0 {
+			t.Weight = 1
+		}
+
+		if t.message, err = ParseTemplate(t.Message); err != nil {
+			return fmt.Errorf("template %d: message: %v", i+1, err)
+		}
+		for _, src := range t.Sources {
+			parsed, err := ParseTemplate(src)
+			if err != nil {
+				return fmt.Errorf("template %d: source: %v", i+1, err)
+			}
+			t.sources = append(t.sources, parsed)
+		}
+		if t.details, err = parseDetailTemplates(t.Details); err != nil {
+			return fmt.Errorf("template %d: %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Scenario returns the file definition as a scenario that can be run by a generator
+func (f *ScenarioFile) Scenario() Scenario {
+	return Scenario{
+		Name:        f.Name,
+		Description: f.Description,
+		Generator:   f.run,
+	}
+}
+
+// run runs the phases of the scenario one after the other, until the run duration has
+// elapsed if one is set
+func (f *ScenarioFile) run(ctx context.Context, g *Generator, opts ScenarioOptions) (ScenarioResult, error) {
+	if err := f.checkOptions(opts); err != nil {
+		return ScenarioResult{}, err
+	}
+
+	g.startProgress(f.expectedCount(opts), opts.Duration)
+	rec := g.newRecorder()
+	start := time.Now()
+
+	index := 0
+	for _, phase := range f.Phases {
+		var limit time.Duration
+		if opts.Duration > 0 {
+			if limit = opts.Duration - time.Since(start); limit <= 0 {
+				break
+			}
+		}
+		if err := f.runPhase(ctx, g, rec, phase, opts, limit, &index); err != nil {
+			return rec.result(start), err
+		}
+	}
+
+	return rec.result(start), nil
+}
+
+// checkOptions checks that the run options apply to the phases and that every phase ends
+func (f *ScenarioFile) checkOptions(opts ScenarioOptions) error {
+	if len(opts.Stages) > 0 {
+		return fmt.Errorf("load profiles aren't supported by file scenarios, set the rate of each phase instead")
+	}
+
+	// A run duration bounds every phase
+	if opts.Duration > 0 || opts.Count > 0 {
+		return nil
+	}
+	for _, phase := range f.Phases {
+		if phase.Count == 0 && phase.duration == 0 {
+			return fmt.Errorf("phase %s never ends: set its count or duration, or a count or duration for the run", phase.Name)
+		}
+	}
+	return nil
+}
+
+// expectedCount returns the number of alerts a run generates, or zero when a phase or
+// the run is limited in time
+func (f *ScenarioFile) expectedCount(opts ScenarioOptions) int {
+	if opts.Duration > 0 {
+		return 0
+	}
+
+	total := 0
+	for _, phase := range f.Phases {
+		if phase.duration > 0 {
+			return 0
+		}
+		if phase.Count > 0 {
+			total += phase.Count
+		} else {
+			total += opts.Count
+		}
+	}
+	return total
+}
+
+// runPhase generates the alerts of a phase from a pool of workers, for at most limit
+// unless it is zero. index numbers the alerts across phases.
+func (f *ScenarioFile) runPhase(ctx context.Context, g *Generator, rec *recorder, phase Phase, opts ScenarioOptions, limit time.Duration, index *int) error {
+	phaseOpts := ScenarioOptions{
+		Count:       phase.Count,
+		Interval:    phase.Interval,
+		Rate:        phase.Rate,
+		Concurrency: phase.Concurrency,
+		Duration:    phase.duration,
+	}
+	if limit > 0 && (phaseOpts.Duration == 0 || limit < phaseOpts.Duration) {
+		phaseOpts.Duration = limit
+	}
+	if phaseOpts.Count == 0 && phaseOpts.Duration == 0 {
+		phaseOpts.Count = opts.Count
+	}
+	if phaseOpts.Interval == 0 && phaseOpts.Rate == 0 {
+		phaseOpts.Interval = opts.Interval
+		phaseOpts.Rate = opts.Rate
+	}
+	if phaseOpts.Concurrency == 0 {
+		phaseOpts.Concurrency = max(opts.Concurrency, 1)
+	}
+
+	wg := &sync.WaitGroup{}
+	jobs := make(chan int)
+
+	for w := 0; w < phaseOpts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				g.send(ctx, rec, f.newAlert(i, phase.Name))
+			}
+		}()
+	}
+
+	// The pacer ends the phase once its duration has elapsed, in-flight alerts still complete
+	pace := newPacer(phaseOpts)
+	for n := 0; phaseOpts.Count == 0 || n < phaseOpts.Count; n++ {
+		if pace.Wait(ctx) != nil {
+			break
+		}
+		jobs <- *index
+		*index++
+	}
+	close(jobs)
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// newAlert builds the alert with the given index from a weighted random template
+func (f *ScenarioFile) newAlert(i int, phase string) provider.Alert {
+	t := f.pickTemplate()
+
+	severities := t.Severities
+	if len(severities) == 0 {
+		severities = f.Severities
+	}
+	priorities := t.Priorities
+	if len(priorities) == 0 {
+		priorities = f.Priorities
+	}
+
+	data := NewTemplateData(f.Name, phase, i)
+
+	source := "scenario-" + f.Name
+	if len(t.sources) > 0 {
+		source = t.sources[rand.Intn(len(t.sources))].Render(data)
+	}
+
+	details := map[string]interface{}{
+		"scenario": f.Name,
+		"phase":    phase,
+		"index":    i,
+	}
+	renderDetails(details, f.details, data)
+	renderDetails(details, t.details, data)
+
+	return provider.Alert{
+		ID:        fmt.Sprintf("%s-%d", f.Name, i),
+		Message:   t.message.Render(data),
+		Severity:  pickWeighted(severities, "warning"),
+		Priority:  pickWeighted(priorities, "medium"),
+		Source:    source,
+		Timestamp: time.Now(),
+		Details:   details,
+	}
+}
+
+// pickTemplate returns a random template according to the template weights
+func (f *ScenarioFile) pickTemplate() AlertTemplate {
+	total := 0
+	for _, t := range f.Templates {
+		total += t.Weight
+	}
+
+	n := rand.Intn(total)
+	for _, t := range f.Templates {
+		if n < t.Weight {
+			return t
+		}
+		n -= t.Weight
+	}
+	return f.Templates[len(f.Templates)-1]
+}
+
+// pickWeighted returns a random key of weights with a probability proportional to its
+// weight, or def if there are no positive weights
+func pickWeighted(weights map[string]int, def string) string {
+	keys := make([]string, 0, len(weights))
+	total := 0
+	for k, w := range weights {
+		if w > 0 {
+			keys = append(keys, k)
+			total += w
+		}
+	}
+	if total == 0 {
+		return def
+	}
+
+	// Sort the keys so a given random number always maps to the same value
+	sort.Strings(keys)
+	n := rand.Intn(total)
+	for _, k := range keys {
+		if n < weights[k] {
+			return k
+		}
+		n -= weights[k]
+	}
+	return keys[len(keys)-1]
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// generateFlappingScenario repeatedly triggers and resolves a small set of alerts with
+// stable IDs, so dedup and flap suppression can be observed on the provider side.
+// Count is the total number of trigger and resolve events across all alerts, unless the
+// run is limited in time.
+func generateFlappingScenario(ctx context.Context, g *Generator, opts ScenarioOptions) (ScenarioResult, error) {
+	keys := intParam(opts.Params, "keys", 5)
+	flapInterval := intParam(opts.Params, "flap_interval", 1000) // milliseconds
+	jitter := intParam(opts.Params, "jitter", 0)                 // milliseconds
+
+	if keys < 1 {
+		return ScenarioResult{}, fmt.Errorf("keys must be at least 1, got %d", keys)
+	}
+
+	// Flap timing is per alert, only a target rate caps the events across all alerts
+	pace := newPacer(ScenarioOptions{Rate: opts.Rate, Stages: opts.Stages, Duration: opts.Duration})
+
+	wg := &sync.WaitGroup{}
+	rec := g.newRecorder()
+	start := time.Now()
+
+	for k := 0; k < keys; k++ {
+		// Spread the events evenly over the keys
+		events := opts.Count / keys
+		if k < opts.Count%keys {
+			events++
+		}
+
+		wg.Add(1)
+		go func(k, events int) {
+			defer wg.Done()
+
+			id := fmt.Sprintf("flapping-%d", k)
+			timed := opts.timed()
+			for e := 0; timed || e < events; e++ {
+				if pace.Wait(ctx) != nil {
+					return
+				}
+
+				if e%2 == 0 {
+					g.send(ctx, rec, provider.Alert{
+						ID:        id,
+						Message:   fmt.Sprintf("Flapping alert #%d", k),
+						Severity:  "warning",
+						Priority:  "medium",
+						Source:    "scenario-flapping",
+						Timestamp: time.Now(),
+						Details: map[string]interface{}{
+							"scenario": "flapping",
+							"key":      k,
+							"flap":     e / 2,
+						},
+					})
+				} else {
+					err := rec.call(ctx, func(ctx context.Context) error { return g.provider.ResolveAlert(ctx, id) })
+					rec.done(err == nil)
+				}
+
+				if timed || e < events-1 {
+					delay := flapInterval
+					if jitter > 0 {
+						delay += rand.Intn(2*jitter+1) - jitter
+					}
+					if pace.pause(ctx, time.Duration(delay)*time.Millisecond) != nil {
+						return
+					}
+				}
+			}
+		}(k, events)
+	}
+
+	wg.Wait()
+
+	return rec.result(start), ctx.Err()
+}
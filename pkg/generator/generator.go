@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
 	"sync"
 	"time"
 
@@ -13,22 +14,60 @@ import (
 // Generator handles generating alerts for scenarios
 type Generator struct {
 	provider provider.Provider
+
+	mu       sync.Mutex
+	progress progressState
 }
 
 // ScenarioOptions configures how a scenario is run
 type ScenarioOptions struct {
 	Count       int
 	Interval    int
+	Rate        float64 // target alerts per second, overrides Interval when set
+	Arrival     string  // open-loop arrival process, empty for closed-loop worker pools
 	Concurrency int
+	MaxInFlight int // cap on open-loop alerts in flight, zero for unbounded
 	Params      map[string]string
+	Stages      []Stage       // load profile, overrides Count, Rate and Interval when set
+	Duration    time.Duration // run length, overrides Count when set
+}
+
+// more reports whether alert i should be generated. A run with a load profile or a
+// duration only ends when the pacer says so.
+func (opts ScenarioOptions) more(i int) bool {
+	return opts.timed() || i < opts.Count
+}
+
+// runLength returns how long a run lasts, the shorter of the load profile and the
+// duration, or zero when the run is limited by the alert count
+func (opts ScenarioOptions) runLength() time.Duration {
+	length := opts.Duration
+	if d := profile(opts.Stages).duration(); d > 0 && (length <= 0 || d < length) {
+		length = d
+	}
+	return length
+}
+
+// timed reports whether the run is limited in time rather than by the alert count
+func (opts ScenarioOptions) timed() bool {
+	return len(opts.Stages) > 0 || opts.Duration > 0
 }
 
 // ScenarioResult contains the results of a scenario run
 type ScenarioResult struct {
 	Sent     int
 	Failed   int
+	Retries  int
 	Duration time.Duration
 	Rate     float64
+	Latency  *Histogram   // latency of every provider request
+	Errors   []ErrorClass // failed provider requests by class, most frequent first
+
+	// How far behind schedule alerts were sent, and arrivals dropped because MaxInFlight
+	// alerts were in flight, only set in open-loop mode
+	AvgLag  time.Duration
+	MaxLag  time.Duration
+	Dropped int
 }
 
 // Scenario represents a predefined alert generation scenario
@@ -36,6 +75,7 @@ type Scenario struct {
 	Name        string
 	Description string
 	Generator   func(context.Context, *Generator, ScenarioOptions) (ScenarioResult, error)
+	OpenLoop    bool // whether the scenario can follow an arrival process
 }
 
 // NewGenerator creates a new alert generator
@@ -52,6 +92,20 @@ func (g *Generator) RunScenario(ctx context.Context, name string, opts ScenarioO
 		return ScenarioResult{}, fmt.Errorf("unknown scenario: %s", name)
 	}
 
+	return g.Run(ctx, scenario, opts)
+}
+
+// Run runs a scenario, such as one loaded from a scenario file
+func (g *Generator) Run(ctx context.Context, scenario Scenario, opts ScenarioOptions) (ScenarioResult, error) {
+	if opts.Arrival != "" && !scenario.OpenLoop {
+		return ScenarioResult{}, fmt.Errorf("scenario %s doesn't support arrival processes, only closed-loop workers", scenario.Name)
+	}
+
+	if opts.timed() {
+		g.startProgress(0, opts.runLength())
+	} else {
+		g.startProgress(opts.Count, 0)
+	}
 	return scenario.Generator(ctx, g, opts)
 }
 
@@ -75,6 +129,7 @@ var scenarios = map[string]Scenario{
 		Name:        "random",
 		Description: "Generates alerts with random severities and priorities",
 		Generator:   generateRandomScenario,
+		OpenLoop:    true,
 	},
 	"burst": {
 		Name:        "burst",
@@ -85,170 +140,137 @@ var scenarios = map[string]Scenario{
 		Name:        "mixed",
 		Description: "Mix of different alert types and severities",
 		Generator:   generateMixedScenario,
+		OpenLoop:    true,
+	},
+	"lifecycle": {
+		Name:        "lifecycle",
+		Description: "Triggers, acknowledges and resolves alerts after a time-to-ack and time-to-resolve",
+		Generator:   generateLifecycleScenario,
+	},
+	"flapping": {
+		Name:        "flapping",
+		Description: "Repeatedly triggers and resolves a small set of alerts with stable IDs",
+		Generator:   generateFlappingScenario,
 	},
 }
 
+// intParam returns the integer value of a scenario parameter or def if it is not set
+func intParam(params map[string]string, name string, def int) int {
+	if v, ok := params[name]; ok {
+		fmt.Sscanf(v, "%d", &def)
+	}
+	return def
+}
+
+// stringParam returns the value of a scenario parameter or def if it is not set
+func stringParam(params map[string]string, name string, def string) string {
+	if v, ok := params[name]; ok {
+		return v
+	}
+	return def
+}
+
 // generateEscalatingScenario generates alerts with escalating severity
 func generateEscalatingScenario(ctx context.Context, g *Generator, opts ScenarioOptions) (ScenarioResult, error) {
 	severities := []string{"info", "warning", "error", "critical"}
 	priorities := []string{"low", "medium", "high", "critical"}
-	
+
 	total := opts.Count
-	interval := time.Duration(opts.Interval) * time.Millisecond
-	result := ScenarioResult{}
-	
+	pace := newPacer(opts)
+	rec := g.newRecorder()
+
 	start := time.Now()
-	
-	for i := 0; i < total; i++ {
-		select {
-		case <-ctx.Done():
-			return result, ctx.Err()
-		default:
-			// Calculate severity index based on progress
-			severityIndex := (i * len(severities)) / total
-			if severityIndex >= len(severities) {
-				severityIndex = len(severities) - 1
-			}
-			
-			priorityIndex := (i * len(priorities)) / total
-			if priorityIndex >= len(priorities) {
-				priorityIndex = len(priorities) - 1
-			}
-			
-			alert := provider.Alert{
-				ID:        fmt.Sprintf("escalating-%d", i),
-				Message:   fmt.Sprintf("Escalating alert #%d", i),
-				Severity:  severities[severityIndex],
-				Priority:  priorities[priorityIndex],
-				Source:    "scenario-escalating",
-				Timestamp: time.Now(),
-				Details: map[string]interface{}{
-					"scenario": "escalating",
-					"progress": float64(i) / float64(total),
-					"index":    i,
-				},
-			}
-			
-			err := g.provider.SendAlert(ctx, alert)
-			if err != nil {
-				result.Failed++
-			} else {
-				result.Sent++
-			}
-			
-			// Sleep for the interval unless it's the last alert
-			if i < total-1 {
-				time.Sleep(interval)
-			}
+
+	for i := 0; opts.more(i); i++ {
+		if err := pace.Wait(ctx); err != nil {
+			return rec.result(start), paceErr(err)
 		}
+
+		// Calculate severity index based on progress
+		progress := pace.progress(i, total)
+		severityIndex := int(progress * float64(len(severities)))
+		if severityIndex >= len(severities) {
+			severityIndex = len(severities) - 1
+		}
+
+		priorityIndex := int(progress * float64(len(priorities)))
+		if priorityIndex >= len(priorities) {
+			priorityIndex = len(priorities) - 1
+		}
+
+		g.send(ctx, rec, provider.Alert{
+			ID:        fmt.Sprintf("escalating-%d", i),
+			Message:   fmt.Sprintf("Escalating alert #%d", i),
+			Severity:  severities[severityIndex],
+			Priority:  priorities[priorityIndex],
+			Source:    "scenario-escalating",
+			Timestamp: time.Now(),
+			Details: map[string]interface{}{
+				"scenario": "escalating",
+				"progress": progress,
+				"index":    i,
+			},
+		})
 	}
-	
-	result.Duration = time.Since(start)
-	if result.Duration.Seconds() > 0 {
-		result.Rate = float64(result.Sent) / result.Duration.Seconds()
-	}
-	
-	return result, nil
+
+	return rec.result(start), nil
 }
 
 // generateRandomScenario generates alerts with random properties
 func generateRandomScenario(ctx context.Context, g *Generator, opts ScenarioOptions) (ScenarioResult, error) {
 	severities := []string{"info", "warning", "error", "critical"}
 	priorities := []string{"low", "medium", "high", "critical"}
-	
-	// Set up worker pool
-	wg := &sync.WaitGroup{}
-	jobs := make(chan int, opts.Count)
-	results := make(chan bool, opts.Count)
-	
-	// Start the workers
-	for w := 0; w < opts.Concurrency; w++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for i := range jobs {
-				sevIdx := rand.Intn(len(severities))
-				priIdx := rand.Intn(len(priorities))
-				
-				alert := provider.Alert{
-					ID:        fmt.Sprintf("random-%d", i),
-					Message:   fmt.Sprintf("Random alert #%d", i),
-					Severity:  severities[sevIdx],
-					Priority:  priorities[priIdx],
-					Source:    "scenario-random",
-					Timestamp: time.Now(),
-					Details: map[string]interface{}{
-						"scenario": "random",
-						"index":    i,
-					},
-				}
-				
-				err := g.provider.SendAlert(ctx, alert)
-				results <- (err == nil)
-			}
-		}()
-	}
-	
-	// Send jobs to the workers
-	start := time.Now()
-	go func() {
-		for i := 0; i < opts.Count; i++ {
-			jobs <- i
-			// Add a small delay between job submissions to control the rate
-			if opts.Interval > 0 {
-				time.Sleep(time.Duration(opts.Interval) * time.Millisecond)
-			}
-		}
-		close(jobs)
-	}()
-	
-	// Collect results
-	result := ScenarioResult{}
-	for i := 0; i < opts.Count; i++ {
-		if <-results {
-			result.Sent++
-		} else {
-			result.Failed++
+
+	newAlert := func(i int) provider.Alert {
+		sevIdx := rand.Intn(len(severities))
+		priIdx := rand.Intn(len(priorities))
+
+		return provider.Alert{
+			ID:        fmt.Sprintf("random-%d", i),
+			Message:   fmt.Sprintf("Random alert #%d", i),
+			Severity:  severities[sevIdx],
+			Priority:  priorities[priIdx],
+			Source:    "scenario-random",
+			Timestamp: time.Now(),
+			Details: map[string]interface{}{
+				"scenario": "random",
+				"index":    i,
+			},
 		}
 	}
-	
-	// Wait for all workers to finish
-	wg.Wait()
-	close(results)
-	
-	result.Duration = time.Since(start)
-	if result.Duration.Seconds() > 0 {
-		result.Rate = float64(result.Sent) / result.Duration.Seconds()
+
+	if opts.Arrival != "" {
+		return runOpenLoop(ctx, g, opts, newAlert)
 	}
-	
-	return result, nil
+	return runWorkerPool(ctx, g, opts, newAlert)
 }
 
 // generateBurstScenario generates alerts in bursts with pauses in between
 func generateBurstScenario(ctx context.Context, g *Generator, opts ScenarioOptions) (ScenarioResult, error) {
 	severities := []string{"info", "warning", "error", "critical"}
-	burstSize := 10
-	if v, ok := opts.Params["burst_size"]; ok {
-		fmt.Sscanf(v, "%d", &burstSize)
-	}
-	
-	pauseDuration := 2000 // milliseconds
-	if v, ok := opts.Params["pause_duration"]; ok {
-		fmt.Sscanf(v, "%d", &pauseDuration)
-	}
-	
+	burstSize := intParam(opts.Params, "burst_size", 10)
+	pauseDuration := intParam(opts.Params, "pause_duration", 2000) // milliseconds
+
+	pace := newPacer(opts)
+	rec := g.newRecorder()
 	start := time.Now()
-	result := ScenarioResult{}
-	
-	for i := 0; i < opts.Count; i++ {
+
+	for i := 0; opts.more(i); i++ {
 		// Determine if this is a burst boundary
 		if i > 0 && i%burstSize == 0 {
-			fmt.Printf("Pausing for %d ms after burst\n", pauseDuration)
-			time.Sleep(time.Duration(pauseDuration) * time.Millisecond)
+			// Stdout may carry a report, so progress notes go to stderr
+			fmt.Fprintf(os.Stderr, "Pausing for %d ms after burst\n", pauseDuration)
+			if err := pace.pause(ctx, time.Duration(pauseDuration)*time.Millisecond); err != nil {
+				return rec.result(start), err
+			}
 		}
-		
+		// Pace every alert, the first one of a burst too
+		if err := pace.Wait(ctx); err != nil {
+			return rec.result(start), paceErr(err)
+		}
+
 		sevIdx := rand.Intn(len(severities))
-		alert := provider.Alert{
+		g.send(ctx, rec, provider.Alert{
 			ID:        fmt.Sprintf("burst-%d", i),
 			Message:   fmt.Sprintf("Burst alert #%d", i),
 			Severity:  severities[sevIdx],
@@ -260,113 +282,103 @@ func generateBurstScenario(ctx context.Context, g *Generator, opts ScenarioOptio
 				"index":      i,
 				"burstIndex": i % burstSize,
 			},
-		}
-		
-		err := g.provider.SendAlert(ctx, alert)
-		if err != nil {
-			result.Failed++
-		} else {
-			result.Sent++
-		}
-		
-		// Sleep within a burst
-		if i%burstSize != burstSize-1 && i < opts.Count-1 {
-			time.Sleep(time.Duration(opts.Interval) * time.Millisecond)
-		}
-	}
-	
-	result.Duration = time.Since(start)
-	if result.Duration.Seconds() > 0 {
-		result.Rate = float64(result.Sent) / result.Duration.Seconds()
+		})
 	}
-	
-	return result, nil
+
+	return rec.result(start), nil
 }
 
 // generateMixedScenario generates a mix of different alert types
 func generateMixedScenario(ctx context.Context, g *Generator, opts ScenarioOptions) (ScenarioResult, error) {
 	templates := []struct {
-		severity  string
-		priority  string
-		message   string
-		category  string
+		severity string
+		priority string
+		message  *Template
+		category string
 	}{
-		{"info", "low", "System startup complete", "system"},
-		{"info", "low", "User logged in", "user"},
-		{"warning", "medium", "High CPU usage detected", "performance"},
-		{"warning", "medium", "Low disk space", "system"},
-		{"error", "high", "Database connection failed", "database"},
-		{"error", "high", "Authentication failure", "security"},
-		{"critical", "critical", "Service unavailable", "service"},
-		{"critical", "critical", "Security breach detected", "security"},
+		{"info", "low", MustParseTemplate("System startup complete on {{ .Host }}"), "system"},
+		{"info", "low", MustParseTemplate(`User {{ pick "alice" "bob" "carol" "dave" }} logged in from {{ .IP }}`), "user"},
+		{"warning", "medium", MustParseTemplate("High CPU usage detected on {{ .Host }} ({{ randInt 85 99 }}%)"), "performance"},
+		{"warning", "medium", MustParseTemplate("Low disk space on {{ .Host }} ({{ randInt 1 10 }}% free)"), "system"},
+		{"error", "high", MustParseTemplate("Database connection failed for {{ .Service }} in {{ .Region }}"), "database"},
+		{"error", "high", MustParseTemplate("Authentication failure for {{ .Service }} from {{ .IP }}"), "security"},
+		{"critical", "critical", MustParseTemplate("Service {{ .Service }} unavailable in {{ .Region }}"), "service"},
+		{"critical", "critical", MustParseTemplate("Security breach detected on {{ .Host }}"), "security"},
 	}
-	
-	start := time.Now()
-	result := ScenarioResult{}
-	
+
+	newAlert := func(i int) provider.Alert {
+		// Select a random template
+		tmpl := templates[rand.Intn(len(templates))]
+		data := NewTemplateData("mixed", "", i)
+
+		return provider.Alert{
+			ID:        fmt.Sprintf("mixed-%d", i),
+			Message:   fmt.Sprintf("%s (%d)", tmpl.message.Render(data), i),
+			Severity:  tmpl.severity,
+			Priority:  tmpl.priority,
+			Source:    "scenario-mixed",
+			Timestamp: time.Now(),
+			Details: map[string]interface{}{
+				"scenario": "mixed",
+				"category": tmpl.category,
+				"index":    i,
+				"host":     data.Host,
+				"region":   data.Region,
+				"service":  data.Service,
+			},
+		}
+	}
+
+	if opts.Arrival != "" {
+		return runOpenLoop(ctx, g, opts, newAlert)
+	}
+	return runWorkerPool(ctx, g, opts, newAlert)
+}
+
+// runWorkerPool sends opts.Count alerts built by newAlert, or alerts until the run is
+// over, from a pool of opts.Concurrency workers, with job submissions controlled by the pacer
+func runWorkerPool(ctx context.Context, g *Generator, opts ScenarioOptions, newAlert func(int) provider.Alert) (ScenarioResult, error) {
+	rec := g.newRecorder()
+
 	// Set up worker pool
 	wg := &sync.WaitGroup{}
-	jobs := make(chan int, opts.Count)
-	results := make(chan bool, opts.Count)
-	
+	workers := max(opts.Concurrency, 1)
+	jobs := make(chan int, workers)
+
 	// Start the workers
-	for w := 0; w < opts.Concurrency; w++ {
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for i := range jobs {
-				// Select a random template
-				tmpl := templates[rand.Intn(len(templates))]
-				
-				alert := provider.Alert{
-					ID:        fmt.Sprintf("mixed-%d", i),
-					Message:   fmt.Sprintf("%s (%d)", tmpl.message, i),
-					Severity:  tmpl.severity,
-					Priority:  tmpl.priority,
-					Source:    "scenario-mixed",
-					Timestamp: time.Now(),
-					Details: map[string]interface{}{
-						"scenario": "mixed",
-						"category": tmpl.category,
-						"index":    i,
-					},
+				// Drop queued alerts once the run is cancelled
+				if ctx.Err() != nil {
+					continue
 				}
-				
-				err := g.provider.SendAlert(ctx, alert)
-				results <- (err == nil)
+				g.send(ctx, rec, newAlert(i))
 			}
 		}()
 	}
-	
+
 	// Send jobs to the workers
-	go func() {
-		for i := 0; i < opts.Count; i++ {
-			jobs <- i
-			// Add a small delay between job submissions
-			if opts.Interval > 0 {
-				time.Sleep(time.Duration(opts.Interval) * time.Millisecond)
-			}
-		}
-		close(jobs)
-	}()
-	
-	// Collect results
-	for i := 0; i < opts.Count; i++ {
-		if <-results {
-			result.Sent++
-		} else {
-			result.Failed++
+	start := time.Now()
+	pace := newPacer(opts)
+	var err error
+	for i := 0; opts.more(i); i++ {
+		// Wait for the pacer to control the rate of job submissions
+		if err = pace.Wait(ctx); err != nil {
+			break
 		}
+		jobs <- i
 	}
-	
+	close(jobs)
+
 	// Wait for all workers to finish
 	wg.Wait()
-	close(results)
-	
-	result.Duration = time.Since(start)
-	if result.Duration.Seconds() > 0 {
-		result.Rate = float64(result.Sent) / result.Duration.Seconds()
+
+	// Queued alerts were dropped if the run was cancelled after the last submission
+	if err = paceErr(err); err == nil {
+		err = ctx.Err()
 	}
-	
-	return result, nil
+	return rec.result(start), err
 }
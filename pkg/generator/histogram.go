@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the precision of the histogram: each power of two range is split
+// into 2^subBucketBits linear sub-buckets, which keeps the relative error under ~3%
+const subBucketBits = 5
+
+// exactBuckets is the number of values below which every microsecond has its own bucket
+const exactBuckets = 2 << subBucketBits
+
+// Histogram is an HDR-style latency histogram with log-linear buckets at microsecond
+// resolution. It is not safe for concurrent use.
+type Histogram struct {
+	counts []int64
+	total  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// HistogramBucket is a non-empty bucket of a histogram
+type HistogramBucket struct {
+	From  time.Duration
+	To    time.Duration
+	Count int64
+}
+
+// NewHistogram creates a new empty histogram
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a latency to the histogram
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	idx := bucketIndex(uint64(d / time.Microsecond))
+	if idx >= len(h.counts) {
+		h.counts = append(h.counts, make([]int64, idx-len(h.counts)+1)...)
+	}
+	h.counts[idx]++
+
+	if h.total == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.total++
+	h.sum += d
+}
+
+// Count returns the number of recorded latencies
+func (h *Histogram) Count() int64 {
+	return h.total
+}
+
+// Min returns the lowest recorded latency
+func (h *Histogram) Min() time.Duration {
+	return h.min
+}
+
+// Max returns the highest recorded latency
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Mean returns the average recorded latency
+func (h *Histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.total)
+}
+
+// Percentile returns the latency at or below which p percent of the values fall
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(h.total)))
+	rank = max(rank, 1)
+
+	var seen int64
+	for idx, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			_, to := bucketRange(idx)
+			return min(to, h.max)
+		}
+	}
+	return h.max
+}
+
+// Buckets returns the non-empty buckets of the histogram in increasing order
+func (h *Histogram) Buckets() []HistogramBucket {
+	var result []HistogramBucket
+	for idx, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		from, to := bucketRange(idx)
+		result = append(result, HistogramBucket{From: from, To: to, Count: c})
+	}
+	return result
+}
+
+// bucketIndex returns the bucket of a value in microseconds
+func bucketIndex(v uint64) int {
+	if v < exactBuckets {
+		return int(v)
+	}
+
+	// Keep the top subBucketBits+1 bits of the value
+	shift := bits.Len64(v) - subBucketBits - 1
+	sub := int(v>>shift) - exactBuckets/2
+	return exactBuckets + (shift-1)*(exactBuckets/2) + sub
+}
+
+// bucketRange returns the inclusive range of latencies covered by a bucket
+func bucketRange(idx int) (time.Duration, time.Duration) {
+	if idx < exactBuckets {
+		d := time.Duration(idx) * time.Microsecond
+		return d, d
+	}
+
+	k := idx - exactBuckets
+	shift := k/(exactBuckets/2) + 1
+	from := uint64(k%(exactBuckets/2)+exactBuckets/2) << shift
+	to := from + 1<<shift - 1
+	return time.Duration(from) * time.Microsecond, time.Duration(to) * time.Microsecond
+}
@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketIndex(t *testing.T) {
+	tests := []struct {
+		v        uint64
+		idx      int
+		from, to time.Duration
+	}{
+		{0, 0, 0, 0},
+		{1, 1, time.Microsecond, time.Microsecond},
+		{63, 63, 63 * time.Microsecond, 63 * time.Microsecond},
+		{64, 64, 64 * time.Microsecond, 65 * time.Microsecond},
+		{65, 64, 64 * time.Microsecond, 65 * time.Microsecond},
+		{66, 65, 66 * time.Microsecond, 67 * time.Microsecond},
+		{127, 95, 126 * time.Microsecond, 127 * time.Microsecond},
+		{128, 96, 128 * time.Microsecond, 131 * time.Microsecond},
+		{1000, 190, 992 * time.Microsecond, 1007 * time.Microsecond},
+		{1000000, 509, 999424 * time.Microsecond, 1015807 * time.Microsecond},
+	}
+
+	for _, tt := range tests {
+		idx := bucketIndex(tt.v)
+		if idx != tt.idx {
+			t.Errorf("bucketIndex(%d) = %d, want %d", tt.v, idx, tt.idx)
+			continue
+		}
+		from, to := bucketRange(idx)
+		if from != tt.from || to != tt.to {
+			t.Errorf("bucketRange(%d) = [%v, %v], want [%v, %v]", idx, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestBucketRangeContainsValue(t *testing.T) {
+	for _, v := range []uint64{64, 100, 4095, 4096, 65537, 1 << 30, 1<<40 + 12345} {
+		from, to := bucketRange(bucketIndex(v))
+		d := time.Duration(v) * time.Microsecond
+		if d < from || d > to {
+			t.Errorf("%d µs is outside its bucket [%v, %v]", v, from, to)
+		}
+		// Buckets are at most 1/32 of their lower bound wide
+		if width := to - from + time.Microsecond; width*32 > from {
+			t.Errorf("bucket [%v, %v] of %d µs is too wide", from, to, v)
+		}
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples map[time.Duration]int
+		p       float64
+		want    time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single", map[time.Duration]int{5 * time.Millisecond: 1}, 99, 5 * time.Millisecond},
+		{"exact median", linearSamples(100), 50, 50 * time.Microsecond},
+		{"bucket upper bound", linearSamples(100), 99, 99 * time.Microsecond},
+		{"capped at max", linearSamples(100), 100, 100 * time.Microsecond},
+		{"min rank", linearSamples(100), 0, time.Microsecond},
+		{"bimodal p50", bimodalSamples(), 50, 1007 * time.Microsecond},
+		{"bimodal p90", bimodalSamples(), 90, 1007 * time.Microsecond},
+		{"bimodal p95", bimodalSamples(), 95, 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		h := NewHistogram()
+		for d, n := range tt.samples {
+			for range n {
+				h.Record(d)
+			}
+		}
+		if got := h.Percentile(tt.p); got != tt.want {
+			t.Errorf("%s: p%v = %v, want %v", tt.name, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestHistogramStats(t *testing.T) {
+	h := NewHistogram()
+	h.Record(-time.Millisecond)
+	h.Record(2 * time.Millisecond)
+	h.Record(4 * time.Millisecond)
+
+	if h.Count() != 3 {
+		t.Errorf("Count() = %d, want 3", h.Count())
+	}
+	if h.Min() != 0 {
+		t.Errorf("Min() = %v, want 0", h.Min())
+	}
+	if h.Max() != 4*time.Millisecond {
+		t.Errorf("Max() = %v, want 4ms", h.Max())
+	}
+	if h.Mean() != 2*time.Millisecond {
+		t.Errorf("Mean() = %v, want 2ms", h.Mean())
+	}
+
+	var total int64
+	for _, b := range h.Buckets() {
+		total += b.Count
+	}
+	if total != 3 {
+		t.Errorf("buckets hold %d values, want 3", total)
+	}
+}
+
+// linearSamples returns one sample of every microsecond from 1 to n
+func linearSamples(n int) map[time.Duration]int {
+	samples := make(map[time.Duration]int)
+	for i := 1; i <= n; i++ {
+		samples[time.Duration(i)*time.Microsecond] = 1
+	}
+	return samples
+}
+
+// bimodalSamples returns 90 fast and 10 slow samples
+func bimodalSamples() map[time.Duration]int {
+	return map[time.Duration]int{
+		time.Millisecond:       90,
+		100 * time.Millisecond: 10,
+	}
+}
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// generateLifecycleScenario triggers alerts, then acknowledges and resolves each of them
+// after a time-to-ack and time-to-resolve. At most opts.Concurrency lifecycles are open
+// at once, and an alert only counts as sent when its whole lifecycle succeeded.
+func generateLifecycleScenario(ctx context.Context, g *Generator, opts ScenarioOptions) (ScenarioResult, error) {
+	severities := []string{"info", "warning", "error", "critical"}
+	priorities := []string{"low", "medium", "high", "critical"}
+
+	ackDelay := intParam(opts.Params, "ack_delay", 5000)              // milliseconds
+	resolveDelay := intParam(opts.Params, "resolve_delay", 10000)     // milliseconds
+	distribution := stringParam(opts.Params, "distribution", "fixed") // fixed, uniform, exponential
+
+	if _, err := sampleDelay(distribution, 0); err != nil {
+		return ScenarioResult{}, err
+	}
+
+	rec := g.newRecorder()
+
+	// A lifecycle holds its slot from trigger to resolve, which bounds the goroutines and
+	// timers of a fast-paced run
+	slots := make(chan struct{}, max(opts.Concurrency, 1))
+
+	wg := &sync.WaitGroup{}
+	pace := newPacer(opts)
+	start := time.Now()
+
+	var waitErr error
+	for i := 0; opts.more(i); i++ {
+		if waitErr = pace.Wait(ctx); waitErr != nil {
+			break
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			waitErr = ctx.Err()
+		}
+		if waitErr != nil {
+			break
+		}
+		// Waiting for a slot may outlast the run, which then ends without this trigger
+		if pace.done() {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			alert := provider.Alert{
+				ID:        fmt.Sprintf("lifecycle-%d", i),
+				Message:   fmt.Sprintf("Lifecycle alert #%d", i),
+				Severity:  severities[rand.Intn(len(severities))],
+				Priority:  priorities[rand.Intn(len(priorities))],
+				Source:    "scenario-lifecycle",
+				Timestamp: time.Now(),
+				Details: map[string]interface{}{
+					"scenario": "lifecycle",
+					"index":    i,
+				},
+			}
+
+			if err := rec.call(ctx, func(ctx context.Context) error { return g.provider.SendAlert(ctx, alert) }); err != nil {
+				rec.done(false)
+				return
+			}
+
+			// An interrupted run acks and resolves right away, so no alert is left open
+			timeToAck, _ := sampleDelay(distribution, ackDelay)
+			sleep(ctx, timeToAck)
+			if err := rec.call(ctx, func(ctx context.Context) error { return g.provider.AcknowledgeAlert(ctx, alert.ID) }); err != nil {
+				rec.done(false)
+				return
+			}
+
+			timeToResolve, _ := sampleDelay(distribution, resolveDelay)
+			sleep(ctx, timeToResolve)
+			err := rec.call(ctx, func(ctx context.Context) error { return g.provider.ResolveAlert(ctx, alert.ID) })
+			rec.done(err == nil)
+		}(i)
+	}
+
+	wg.Wait()
+
+	// Lifecycles were cut short if the run was cancelled after the last trigger
+	if waitErr = paceErr(waitErr); waitErr == nil {
+		waitErr = ctx.Err()
+	}
+	return rec.result(start), waitErr
+}
+
+// sampleDelay returns a delay in milliseconds around mean following the given distribution
+func sampleDelay(distribution string, mean int) (time.Duration, error) {
+	var ms float64
+	switch distribution {
+	case "fixed":
+		ms = float64(mean)
+	case "uniform":
+		ms = rand.Float64() * 2 * float64(mean)
+	case "exponential":
+		ms = rand.ExpFloat64() * float64(mean)
+	default:
+		return 0, fmt.Errorf("unknown delay distribution: %s", distribution)
+	}
+
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
@@ -0,0 +1,135 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// Arrival processes for open-loop mode
+const (
+	ArrivalConstant = "constant"
+	ArrivalPoisson  = "poisson"
+)
+
+// arrivalStep is the step used to integrate the rate of a load profile in open-loop mode
+const arrivalStep = 10 * time.Millisecond
+
+// runOpenLoop sends opts.Count alerts built by newAlert, or alerts until the run is over,
+// following an arrival process that doesn't depend on response times, unlike the
+// closed-loop worker pools where slow responses throttle the offered load. Each alert is
+// sent from its own goroutine at its scheduled time, and the result reports how far
+// behind schedule the sender fell. With opts.MaxInFlight set, arrivals finding that many
+// alerts in flight are dropped rather than delaying the schedule.
+func runOpenLoop(ctx context.Context, g *Generator, opts ScenarioOptions, newAlert func(int) provider.Alert) (ScenarioResult, error) {
+	rate := opts.Rate
+	if rate <= 0 && opts.Interval > 0 {
+		rate = 1000 / float64(opts.Interval)
+	}
+	if rate <= 0 && len(opts.Stages) == 0 {
+		return ScenarioResult{}, fmt.Errorf("open-loop mode requires a rate or an interval")
+	}
+
+	start := time.Now()
+
+	// Gaps between arrivals at one alert per second, scaled by the target rate
+	var interarrival func() float64
+	switch opts.Arrival {
+	case ArrivalConstant:
+		interarrival = func() float64 { return 1 }
+	case ArrivalPoisson:
+		// Exponentially distributed gaps between arrivals make a Poisson process
+		interarrival = rand.ExpFloat64
+	default:
+		return ScenarioResult{}, fmt.Errorf("unknown arrival process: %s", opts.Arrival)
+	}
+
+	// nextArrival returns the arrival after t. With a load profile the rate is integrated
+	// in small steps, so a slow start of a ramp doesn't postpone the arrivals after it.
+	prof := profile(opts.Stages)
+	nextArrival := func(t time.Time) time.Time {
+		gap := interarrival()
+		if len(prof) == 0 {
+			return t.Add(time.Duration(gap / rate * float64(time.Second)))
+		}
+		for t.Sub(start) < prof.duration() {
+			r := prof.rateAt(t.Sub(start))
+			if r*arrivalStep.Seconds() >= gap {
+				return t.Add(time.Duration(gap / r * float64(time.Second)))
+			}
+			gap -= r * arrivalStep.Seconds()
+			t = t.Add(arrivalStep)
+		}
+		return t
+	}
+
+	wg := &sync.WaitGroup{}
+	rec := g.newRecorder()
+
+	var slots chan struct{}
+	if opts.MaxInFlight > 0 {
+		slots = make(chan struct{}, opts.MaxInFlight)
+	}
+
+	length := opts.runLength()
+	next := start
+	var totalLag, maxLag time.Duration
+	var sent, dropped int
+	var err error
+
+	for i := 0; opts.more(i); i++ {
+		// Without a profile the first alert is sent right away
+		if i > 0 || len(prof) > 0 {
+			next = nextArrival(next)
+		}
+		if length > 0 && next.Sub(start) >= length {
+			break
+		}
+		if err = sleep(ctx, time.Until(next)); err != nil {
+			break
+		}
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			default:
+				dropped++
+				continue
+			}
+		}
+
+		lag := time.Since(next)
+		totalLag += lag
+		maxLag = max(maxLag, lag)
+		sent++
+
+		wg.Add(1)
+		go func(i int, scheduled time.Time) {
+			defer func() {
+				if slots != nil {
+					<-slots
+				}
+				wg.Done()
+			}()
+			alert := newAlert(i)
+			err := rec.callSince(ctx, scheduled, func(ctx context.Context) error {
+				return g.provider.SendAlert(ctx, alert)
+			})
+			rec.done(err == nil)
+		}(i, next)
+	}
+
+	wg.Wait()
+
+	result := rec.result(start)
+	result.MaxLag = maxLag
+	result.Dropped = dropped
+	if sent > 0 {
+		result.AvgLag = totalLag / time.Duration(sent)
+	}
+
+	return result, err
+}
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// pacer controls the pace at which a scenario generates alerts. With a target rate
+// it is backed by a token bucket shared by all workers of the scenario, so the
+// throughput doesn't depend on concurrency or send latency. Otherwise it sleeps
+// for the interval between alerts. With a load profile the token bucket rate follows
+// the profile. The pacer signals the end of the run once the profile is over or the
+// run duration has elapsed.
+type pacer struct {
+	limiter  *rate.Limiter
+	interval time.Duration
+	profile  profile
+	start    time.Time
+	end      time.Time // zero when the run isn't limited in time
+	started  bool
+}
+
+// errPaceDone is returned by pacer.Wait once the run is over
+var errPaceDone = errors.New("run duration elapsed")
+
+// profileTick bounds how long a profile pacer waits before following a rate change
+const profileTick = 100 * time.Millisecond
+
+// newPacer creates a pacer from the scenario options
+func newPacer(opts ScenarioOptions) *pacer {
+	p := &pacer{
+		interval: time.Duration(opts.Interval) * time.Millisecond,
+		profile:  opts.Stages,
+		start:    time.Now(),
+	}
+	if len(p.profile) > 0 {
+		p.limiter = rate.NewLimiter(0, 1)
+	} else if opts.Rate > 0 {
+		p.limiter = rate.NewLimiter(rate.Limit(opts.Rate), 1)
+	}
+
+	if length := opts.runLength(); length > 0 {
+		p.end = p.start.Add(length)
+	}
+	return p
+}
+
+// Wait blocks until the next alert can be generated or ctx is done, and returns
+// errPaceDone once the run is over. Interval pacing keeps state, so a pacer without
+// a target rate must only be used by one goroutine.
+func (p *pacer) Wait(ctx context.Context) error {
+	if p.done() {
+		return errPaceDone
+	}
+
+	if len(p.profile) > 0 {
+		return p.waitProfile(ctx)
+	}
+	if p.limiter != nil {
+		return p.waitToken(ctx)
+	}
+	if p.interval <= 0 {
+		return ctx.Err()
+	}
+
+	// No wait before the first alert
+	if !p.started {
+		p.started = true
+		return ctx.Err()
+	}
+	return p.delay(ctx, p.interval)
+}
+
+// done reports whether the run is over
+func (p *pacer) done() bool {
+	return !p.end.IsZero() && !time.Now().Before(p.end)
+}
+
+// waitToken waits for a token of the rate limiter
+func (p *pacer) waitToken(ctx context.Context) error {
+	res := p.limiter.Reserve()
+	if err := p.delay(ctx, res.Delay()); err != nil {
+		res.Cancel()
+		return err
+	}
+	return nil
+}
+
+// waitProfile waits for a token at the current rate of the load profile. Long waits
+// are cut into ticks so that a rising rate is picked up quickly.
+func (p *pacer) waitProfile(ctx context.Context) error {
+	for {
+		if r := p.profile.rateAt(time.Since(p.start)); r > 0 {
+			p.limiter.SetLimit(rate.Limit(r))
+			res := p.limiter.Reserve()
+			if d := res.Delay(); d <= profileTick {
+				return p.delay(ctx, d)
+			}
+			res.Cancel()
+		}
+
+		if err := p.delay(ctx, profileTick); err != nil {
+			return err
+		}
+	}
+}
+
+// delay sleeps for d or until ctx is done. When the run ends before d has elapsed, it
+// sleeps until the end of the run and returns errPaceDone, so that the run lasts its
+// full duration without sending an alert after the end.
+func (p *pacer) delay(ctx context.Context, d time.Duration) error {
+	if !p.end.IsZero() {
+		if left := time.Until(p.end); left < d {
+			if err := sleep(ctx, left); err != nil {
+				return err
+			}
+			return errPaceDone
+		}
+	}
+	return sleep(ctx, d)
+}
+
+// pause sleeps for d or until ctx is done, without sleeping past the end of the run
+func (p *pacer) pause(ctx context.Context, d time.Duration) error {
+	if !p.end.IsZero() {
+		d = min(d, time.Until(p.end))
+	}
+	return sleep(ctx, d)
+}
+
+// progress returns how far a run is, from 0 to 1, after i of total alerts or, for a
+// run limited in time, by the elapsed time
+func (p *pacer) progress(i, total int) float64 {
+	if !p.end.IsZero() {
+		return min(float64(time.Since(p.start))/float64(p.end.Sub(p.start)), 1)
+	}
+	if total <= 0 {
+		return 0
+	}
+	return float64(i) / float64(total)
+}
+
+// paceErr returns the error a run ends with after the pacer returned err, which is
+// nil when the run is over
+func paceErr(err error) error {
+	if errors.Is(err, errPaceDone) {
+		return nil
+	}
+	return err
+}
+
+// sleep pauses for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stage is a step of a load profile: over Duration the target rate moves linearly from
+// the target of the previous stage (zero for the first one) to Target alerts per second
+type Stage struct {
+	Duration time.Duration
+	Target   float64
+}
+
+// String returns the stage in the duration:target form accepted by ParseStages
+func (s Stage) String() string {
+	return fmt.Sprintf("%v:%s", s.Duration, strconv.FormatFloat(s.Target, 'f', -1, 64))
+}
+
+// ParseStages parses a load profile such as "30s:10,2m:10,10s:200,30s:0", a comma
+// separated list of stage durations and target rates in alerts per second
+func ParseStages(s string) ([]Stage, error) {
+	var stages []Stage
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		d, t, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid stage %q: expected duration:target", part)
+		}
+		duration, err := time.ParseDuration(d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stage %q: %v", part, err)
+		}
+		target, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stage %q: %v", part, err)
+		}
+		if duration <= 0 || target < 0 {
+			return nil, fmt.Errorf("invalid stage %q: duration must be positive and target not negative", part)
+		}
+
+		stages = append(stages, Stage{Duration: duration, Target: target})
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("no stages in %q", s)
+	}
+	return stages, nil
+}
+
+// profile is a load profile made of consecutive stages
+type profile []Stage
+
+// duration returns the total duration of the profile
+func (p profile) duration() time.Duration {
+	var total time.Duration
+	for _, s := range p {
+		total += s.Duration
+	}
+	return total
+}
+
+// rateAt returns the target rate in alerts per second once elapsed has passed since the
+// start of the profile, interpolated linearly within the current stage
+func (p profile) rateAt(elapsed time.Duration) float64 {
+	var from float64
+	for _, s := range p {
+		if elapsed < s.Duration {
+			return from + (s.Target-from)*float64(elapsed)/float64(s.Duration)
+		}
+		elapsed -= s.Duration
+		from = s.Target
+	}
+	return from
+}
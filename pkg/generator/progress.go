@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"time"
+)
+
+// Progress is a snapshot of the scenario a generator is running
+type Progress struct {
+	Sent     int
+	Failed   int
+	InFlight int // provider requests waiting for a response
+	Elapsed  time.Duration
+	Rate     float64       // alerts per second completed since the previous snapshot
+	P95      time.Duration // p95 latency of the requests since the previous snapshot
+	ETA      time.Duration // estimated time left, zero when unknown
+}
+
+// progressState tracks the progress of the current run of a generator
+type progressState struct {
+	rec    *recorder
+	start  time.Time
+	count  int           // expected number of alerts, zero when unknown
+	length time.Duration // expected run length, zero when unknown
+
+	lastAt   time.Time
+	lastDone int
+}
+
+// startProgress resets the progress for a run expected to generate count alerts or to
+// last length
+func (g *Generator) startProgress(count int, length time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.progress = progressState{
+		start:  now,
+		count:  count,
+		length: length,
+		lastAt: now,
+	}
+}
+
+// newRecorder creates the recorder of a run and reports the progress of the run from it
+func (g *Generator) newRecorder() *recorder {
+	rec := newRecorder()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.progress.rec = rec
+	return rec
+}
+
+// Progress returns a snapshot of the running scenario. Rate and P95 cover the time since
+// the previous call, so it is meant to be called periodically by a single reporter.
+func (g *Generator) Progress() Progress {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	st := &g.progress
+	if st.rec == nil {
+		return Progress{}
+	}
+
+	now := time.Now()
+	p := st.rec.snapshot()
+	p.Elapsed = now.Sub(st.start)
+
+	done := p.Sent + p.Failed
+	if since := now.Sub(st.lastAt); since > 0 {
+		p.Rate = float64(done-st.lastDone) / since.Seconds()
+	}
+	st.lastAt, st.lastDone = now, done
+
+	switch {
+	case st.length > 0:
+		p.ETA = max(st.length-p.Elapsed, 0)
+	case st.count > 0 && done > 0:
+		p.ETA = time.Duration(float64(p.Elapsed) * float64(max(st.count-done, 0)) / float64(done))
+	}
+
+	return p
+}
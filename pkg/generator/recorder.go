@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// recorder collects the outcome of a scenario run. It is safe for concurrent use by
+// the workers of a scenario.
+type recorder struct {
+	mu       sync.Mutex
+	sent     int
+	failed   int
+	retries  int
+	inFlight int
+	latency  *Histogram
+	window   *Histogram // latency since the last progress snapshot
+	errors   errorStats
+}
+
+// newRecorder creates a new empty recorder
+func newRecorder() *recorder {
+	return &recorder{
+		latency: NewHistogram(),
+		window:  NewHistogram(),
+		errors:  make(errorStats),
+	}
+}
+
+// call runs a provider request and records its latency, retries and error
+func (r *recorder) call(ctx context.Context, fn func(context.Context) error) error {
+	return r.callSince(ctx, time.Now(), fn)
+}
+
+// callSince runs a provider request and records its retries, error and latency measured
+// from start. Open-loop runs pass the scheduled send time so that latency includes the
+// time spent behind schedule. The request in flight isn't aborted when ctx is cancelled,
+// so that an interrupted run drains its requests, but no further retries are made.
+func (r *recorder) callSince(ctx context.Context, start time.Time, fn func(context.Context) error) error {
+	r.mu.Lock()
+	r.inFlight++
+	r.mu.Unlock()
+
+	info := &provider.RequestInfo{}
+	err := fn(provider.WithRequestInfo(provider.WithDrain(ctx), info))
+	latency := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight--
+	r.latency.Record(latency)
+	r.window.Record(latency)
+	if info.Attempts > 1 {
+		r.retries += info.Attempts - 1
+	}
+	if err != nil {
+		r.errors.add(err)
+	}
+	return err
+}
+
+// done records whether an alert was sent successfully
+func (r *recorder) done(ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ok {
+		r.sent++
+	} else {
+		r.failed++
+	}
+}
+
+// snapshot returns the counts of the run so far and the p95 latency since the previous
+// snapshot
+func (r *recorder) snapshot() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := Progress{
+		Sent:     r.sent,
+		Failed:   r.failed,
+		InFlight: r.inFlight,
+		P95:      r.window.Percentile(95),
+	}
+	r.window = NewHistogram()
+	return p
+}
+
+// result builds the scenario result of a run that began at start
+func (r *recorder) result(start time.Time) ScenarioResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := ScenarioResult{
+		Sent:     r.sent,
+		Failed:   r.failed,
+		Retries:  r.retries,
+		Duration: time.Since(start),
+		Latency:  r.latency,
+		Errors:   r.errors.list(),
+	}
+	if result.Duration.Seconds() > 0 {
+		result.Rate = float64(result.Sent) / result.Duration.Seconds()
+	}
+	return result
+}
+
+// send sends an alert through the generator's provider and records the outcome
+func (g *Generator) send(ctx context.Context, rec *recorder, alert provider.Alert) {
+	err := rec.call(ctx, func(ctx context.Context) error {
+		return g.provider.SendAlert(ctx, alert)
+	})
+	rec.done(err == nil)
+}
@@ -0,0 +1,216 @@
+package generator
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Fake values used by the template helpers
+var (
+	fakeHostPrefixes = []string{"web", "api", "db", "cache", "worker", "queue", "lb", "search"}
+	fakeRegions      = []string{"us-east-1", "us-west-2", "eu-west-1", "eu-central-1", "ap-southeast-1", "ap-northeast-1", "sa-east-1"}
+	fakeServices     = []string{"checkout", "payments", "auth", "inventory", "search", "notifications", "orders", "billing", "gateway", "recommendations"}
+)
+
+// templateFuncs are the helpers available in alert templates. Each call returns a new
+// random value; the fields of TemplateData are stable for all fields of one alert.
+var templateFuncs = template.FuncMap{
+	"hostname": fakeHostname,
+	"region":   fakeRegion,
+	"service":  fakeService,
+	"ip":       fakeIP,
+	"uuid":     fakeUUID,
+	"now": func() string {
+		return time.Now().Format(time.RFC3339)
+	},
+	"randInt": func(lo, hi int) int {
+		if hi <= lo {
+			return lo
+		}
+		return lo + mathrand.Intn(hi-lo+1)
+	},
+	"pick": func(values ...string) string {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[mathrand.Intn(len(values))]
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// TemplateData is the data alert templates are rendered with
+type TemplateData struct {
+	Index     int
+	Scenario  string
+	Phase     string
+	Timestamp string
+	Host      string
+	Region    string
+	Service   string
+	IP        string
+	ID        string
+}
+
+// NewTemplateData returns the data for the alert with the given index, with random fake
+// values shared by all the fields of the alert
+func NewTemplateData(scenario, phase string, index int) TemplateData {
+	return TemplateData{
+		Index:     index,
+		Scenario:  scenario,
+		Phase:     phase,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Host:      fakeHostname(),
+		Region:    fakeRegion(),
+		Service:   fakeService(),
+		IP:        fakeIP(),
+		ID:        fakeUUID(),
+	}
+}
+
+// Template renders an alert field from a Go text/template string
+type Template struct {
+	text string
+	tmpl *template.Template
+}
+
+// ParseTemplate parses an alert template. Text without actions is rendered as is.
+func ParseTemplate(text string) (*Template, error) {
+	t := &Template{text: text}
+	if !strings.Contains(text, "{{") {
+		return t, nil
+	}
+
+	tmpl, err := template.New("alert").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// Render once so unknown fields are reported when loading rather than per alert
+	if err := tmpl.Execute(&strings.Builder{}, NewTemplateData("", "", 0)); err != nil {
+		return nil, err
+	}
+
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// MustParseTemplate is like ParseTemplate but panics on error, for built-in templates
+func MustParseTemplate(text string) *Template {
+	t, err := ParseTemplate(text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render renders the template, falling back to the raw text if it fails
+func (t *Template) Render(data TemplateData) string {
+	if t.tmpl == nil {
+		return t.text
+	}
+
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return t.text
+	}
+	return sb.String()
+}
+
+// parseDetailTemplates parses the string values of details, including nested ones, as templates
+func parseDetailTemplates(details map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(details))
+	for k, v := range details {
+		parsed, err := parseDetailValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("detail %s: %v", k, err)
+		}
+		result[k] = parsed
+	}
+	return result, nil
+}
+
+// parseDetailValue parses a detail value, replacing strings with templates
+func parseDetailValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return ParseTemplate(v)
+	case map[string]interface{}:
+		return parseDetailTemplates(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			parsed, err := parseDetailValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = parsed
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+// renderDetails renders details parsed by parseDetailTemplates into dst
+func renderDetails(dst, details map[string]interface{}, data TemplateData) {
+	for k, v := range details {
+		dst[k] = renderDetailValue(v, data)
+	}
+}
+
+// renderDetailValue renders a detail value parsed by parseDetailValue
+func renderDetailValue(v interface{}, data TemplateData) interface{} {
+	switch v := v.(type) {
+	case *Template:
+		return v.Render(data)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		renderDetails(result, v, data)
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = renderDetailValue(item, data)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// fakeHostname returns a random hostname such as api-07.prod.internal
+func fakeHostname() string {
+	prefix := fakeHostPrefixes[mathrand.Intn(len(fakeHostPrefixes))]
+	return fmt.Sprintf("%s-%02d.prod.internal", prefix, mathrand.Intn(32)+1)
+}
+
+// fakeRegion returns a random cloud region
+func fakeRegion() string {
+	return fakeRegions[mathrand.Intn(len(fakeRegions))]
+}
+
+// fakeService returns a random service name
+func fakeService() string {
+	return fakeServices[mathrand.Intn(len(fakeServices))]
+}
+
+// fakeIP returns a random private IPv4 address
+func fakeIP() string {
+	return fmt.Sprintf("10.%d.%d.%d", mathrand.Intn(256), mathrand.Intn(256), mathrand.Intn(254)+1)
+}
+
+// fakeUUID returns a random version 4 UUID
+func fakeUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/generator"
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Provider actions recorded in the action label
+const (
+	actionSend        = "send"
+	actionAcknowledge = "acknowledge"
+	actionResolve     = "resolve"
+)
+
+// Metrics are the Prometheus metrics of the alerts sent, acknowledged and resolved by
+// scenario runs
+type Metrics struct {
+	registry *prometheus.Registry
+	sent     *prometheus.CounterVec
+	failed   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// New creates the metrics in a registry of their own, along with the Go runtime and
+// process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertcli_alerts_sent_total",
+			Help: "Provider requests that succeeded, by action. Severity is only known when sending.",
+		}, []string{"provider", "scenario", "action", "severity"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "alertcli_alerts_failed_total",
+			Help: "Provider requests that failed, by action and error class.",
+		}, []string{"provider", "scenario", "action", "severity", "error_class"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "alertcli_send_duration_seconds",
+			Help:    "Time of a provider action, including retries.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+		}, []string{"provider", "scenario", "action"}),
+	}
+
+	m.registry.MustRegister(
+		m.sent,
+		m.failed,
+		m.latency,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler returns the HTTP handler serving the metrics on /metrics
+func (m *Metrics) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// Serve serves the metrics on /metrics at addr in the background, until the returned
+// function is called
+func (m *Metrics) Serve(addr string) (stop func(), err error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve metrics: %v", err)
+	}
+
+	srv := &http.Server{Handler: m.Handler()}
+	go srv.Serve(ln)
+
+	return func() { srv.Close() }, nil
+}
+
+// Wrap returns a provider that records the alerts sent, acknowledged and resolved
+// through p by a scenario
+func (m *Metrics) Wrap(p provider.Provider, scenario string) provider.Provider {
+	return &instrumentedProvider{Provider: p, metrics: m, scenario: scenario}
+}
+
+// instrumentedProvider is a provider recording metrics of the requests it makes
+type instrumentedProvider struct {
+	provider.Provider
+	metrics  *Metrics
+	scenario string
+}
+
+// SendAlert sends an alert through the wrapped provider and records its outcome
+func (p *instrumentedProvider) SendAlert(ctx context.Context, alert provider.Alert) error {
+	return p.record(actionSend, alert.Severity, func() error {
+		return p.Provider.SendAlert(ctx, alert)
+	})
+}
+
+// AcknowledgeAlert acknowledges an alert through the wrapped provider and records its outcome
+func (p *instrumentedProvider) AcknowledgeAlert(ctx context.Context, id string) error {
+	return p.record(actionAcknowledge, "", func() error {
+		return p.Provider.AcknowledgeAlert(ctx, id)
+	})
+}
+
+// ResolveAlert resolves an alert through the wrapped provider and records its outcome
+func (p *instrumentedProvider) ResolveAlert(ctx context.Context, id string) error {
+	return p.record(actionResolve, "", func() error {
+		return p.Provider.ResolveAlert(ctx, id)
+	})
+}
+
+// record calls fn and records its duration and outcome for an action
+func (p *instrumentedProvider) record(action, severity string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+
+	name := p.Name()
+	p.metrics.latency.WithLabelValues(name, p.scenario, action).Observe(time.Since(start).Seconds())
+	if err != nil {
+		p.metrics.failed.WithLabelValues(name, p.scenario, action, severity, generator.ClassifyError(err)).Inc()
+	} else {
+		p.metrics.sent.WithLabelValues(name, p.scenario, action, severity).Inc()
+	}
+	return err
+}
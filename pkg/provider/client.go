@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// RetryPolicy configures how failed requests are retried. Rate limited (429), server
+// errors (5xx) and network failures are retried with jittered exponential backoff,
+// waiting for the Retry-After header instead when the provider sends one.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy makes a single attempt and holds the default backoff delays
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 0,
+	BaseDelay:  100 * time.Millisecond,
+	MaxDelay:   10 * time.Second,
+}
+
+// backoff returns the jittered delay before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.MaxDelay
+	if shift := retry - 1; shift < 32 {
+		ceiling = min(p.BaseDelay<<shift, p.MaxDelay)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	// Full jitter spreads out retries of concurrent senders
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// RequestInfo collects details about the HTTP requests made for a single provider call
+type RequestInfo struct {
+	Attempts   int
+	StatusCode int
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context that makes providers fill info during a call
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the request info set on ctx with WithRequestInfo, or nil
+func RequestInfoFrom(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+type drainKey struct{}
+
+// WithDrain returns a context whose cancellation stops retries without aborting the
+// request already in flight, so that an interrupted run can drain its requests
+func WithDrain(ctx context.Context) context.Context {
+	return context.WithValue(ctx, drainKey{}, true)
+}
+
+// Option configures a provider
+type Option func(*client)
+
+// WithRetryPolicy sets the retry policy of a provider
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *client) {
+		c.retry = policy
+	}
+}
+
+// client sends JSON requests to provider APIs
+type client struct {
+	http  *http.Client
+	retry RetryPolicy
+}
+
+// newClient creates a client configured by opts
+func newClient(opts []Option) client {
+	c := client{
+		http:  &http.Client{Timeout: 10 * time.Second},
+		retry: DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// postJSON posts payload as JSON with the given headers, retrying failed attempts
+// according to the retry policy
+func (c *client) postJSON(ctx context.Context, endpoint string, headers map[string]string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	info := RequestInfoFrom(ctx)
+	if info == nil {
+		info = &RequestInfo{}
+	}
+
+	// Only the retries see the cancellation of a draining context
+	attemptCtx := ctx
+	if ctx.Value(drainKey{}) != nil {
+		attemptCtx = context.WithoutCancel(ctx)
+	}
+
+	for retry := 0; ; retry++ {
+		info.Attempts++
+		retryAfter, err := c.attempt(attemptCtx, endpoint, headers, data, info)
+		if err == nil || retry >= c.retry.MaxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		delay := c.retry.backoff(retry + 1)
+		if retryAfter > 0 {
+			// Give up rather than block a sender longer than the policy allows
+			if retryAfter > c.retry.MaxDelay {
+				return err
+			}
+			delay = retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt makes a single request and returns the delay requested by a Retry-After header
+func (c *client) attempt(ctx context.Context, endpoint string, headers map[string]string, data []byte, info *RequestInfo) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	// Propagate the trace context, if tracing is set up
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	info.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 400 {
+		var retryAfter time.Duration
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		return retryAfter, newHTTPError(resp)
+	}
+
+	return 0, nil
+}
+
+// retryable reports whether a failed request should be retried
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+
+	// Transport failures are retried when they are timeouts or connection-level errors,
+	// not permanent ones such as a bad URL or an untrusted certificate
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return false
+	}
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	if urlErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
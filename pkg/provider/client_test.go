@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy retries quickly so tests don't wait on backoff
+var testPolicy = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+
+// scriptedServer answers requests with the given status codes in turn, repeating the
+// last one, and counts the requests it received
+func scriptedServer(t *testing.T, retryAfter string, codes ...int) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		code := codes[min(n, len(codes))-1]
+		if retryAfter != "" && code >= 400 {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestPostJSONRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		retryAfter   string
+		wantAttempts int
+		wantStatus   int
+		wantErr      bool
+	}{
+		{"success", []int{http.StatusAccepted}, "", 1, http.StatusAccepted, false},
+		{"server error then success", []int{500, 202}, "", 2, 202, false},
+		{"bad gateway then success", []int{502, 202}, "", 2, 202, false},
+		{"rate limited then success", []int{429, 429, 202}, "", 3, 202, false},
+		{"retries exhausted", []int{503}, "", 3, 503, true},
+		{"bad request", []int{400}, "", 1, 400, true},
+		{"unauthorized", []int{401}, "", 1, 401, true},
+		{"not found", []int{404, 202}, "", 1, 404, true},
+		{"retry after within max delay", []int{429, 202}, "0", 2, 202, false},
+		{"retry after over max delay", []int{429, 202}, "60", 1, 429, true},
+		{"retry after date over max delay", []int{503, 202}, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 1, 503, true},
+	}
+
+	for _, tt := range tests {
+		srv, requests := scriptedServer(t, tt.retryAfter, tt.codes...)
+		c := newClient([]Option{WithRetryPolicy(testPolicy)})
+
+		info := &RequestInfo{}
+		err := c.postJSON(WithRequestInfo(context.Background(), info), srv.URL, nil, map[string]string{})
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		var httpErr *HTTPError
+		if err != nil && !errors.As(err, &httpErr) {
+			t.Errorf("%s: got error %v, want an HTTPError", tt.name, err)
+		}
+		if info.Attempts != tt.wantAttempts || int(requests.Load()) != tt.wantAttempts {
+			t.Errorf("%s: %d attempts counted, %d requests received, want %d", tt.name, info.Attempts, requests.Load(), tt.wantAttempts)
+		}
+		if info.StatusCode != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d", tt.name, info.StatusCode, tt.wantStatus)
+		}
+	}
+}
+
+func TestPostJSONHonorsRetryAfter(t *testing.T) {
+	srv, requests := scriptedServer(t, "1", 429, 202)
+	c := newClient([]Option{WithRetryPolicy(testPolicy)})
+
+	start := time.Now()
+	if err := c.postJSON(context.Background(), srv.URL, nil, map[string]string{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the 1s of Retry-After", elapsed)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("%d requests, want 2", requests.Load())
+	}
+}
+
+func TestPostJSONTransportErrors(t *testing.T) {
+	// An address nothing listens on refuses connections
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String()
+	ln.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name         string
+		endpoint     string
+		wantAttempts int
+	}{
+		{"connection refused", refused, 3},
+		{"timeout", slow.URL, 3},
+		{"unsupported scheme", "ftp://127.0.0.1/alerts", 1},
+		{"invalid URL", "http://[::1", 1},
+	}
+
+	for _, tt := range tests {
+		c := newClient([]Option{WithRetryPolicy(testPolicy)})
+		c.http.Timeout = 20 * time.Millisecond
+
+		info := &RequestInfo{}
+		err := c.postJSON(WithRequestInfo(context.Background(), info), tt.endpoint, nil, map[string]string{})
+		if err == nil {
+			t.Errorf("%s: got no error", tt.name)
+		}
+		if info.Attempts != tt.wantAttempts {
+			t.Errorf("%s: %d attempts, want %d", tt.name, info.Attempts, tt.wantAttempts)
+		}
+	}
+}
+
+func TestPostJSONDrain(t *testing.T) {
+	tests := []struct {
+		name        string
+		drain       bool
+		wantHTTPErr bool
+	}{
+		// The in-flight attempt completes, but isn't retried
+		{"draining", true, true},
+		// The in-flight attempt is aborted
+		{"not draining", false, false},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+
+		if tt.drain {
+			ctx = WithDrain(ctx)
+		}
+		info := &RequestInfo{}
+		c := newClient([]Option{WithRetryPolicy(testPolicy)})
+		err := c.postJSON(WithRequestInfo(ctx, info), srv.URL, nil, map[string]string{})
+		srv.Close()
+
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) != tt.wantHTTPErr {
+			t.Errorf("%s: got error %v, want an HTTPError %v", tt.name, err, tt.wantHTTPErr)
+		}
+		if !tt.wantHTTPErr && !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: got error %v, want context.Canceled", tt.name, err)
+		}
+		if info.Attempts != 1 {
+			t.Errorf("%s: %d attempts after cancellation, want 1", tt.name, info.Attempts)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"0", 0, 0},
+		{"-5", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(5 * time.Second).UTC().Format(http.TimeFormat), 3 * time.Second, 5 * time.Second},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+
+	for _, tt := range tests {
+		if d := parseRetryAfter(tt.value); d < tt.min || d > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, d, tt.min, tt.max)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		retry   int
+		ceiling time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{64, time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 100 {
+			if d := policy.backoff(tt.retry); d < 0 || d > tt.ceiling {
+				t.Errorf("backoff(%d) = %v, want at most %v", tt.retry, d, tt.ceiling)
+				break
+			}
+		}
+	}
+
+	if d := (RetryPolicy{}).backoff(1); d != 0 {
+		t.Errorf("backoff without delays = %v, want 0", d)
+	}
+}
@@ -1,11 +1,10 @@
 package provider
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -13,6 +12,7 @@ import (
 type OpsGenieProvider struct {
 	apiKey   string
 	endpoint string
+	client   client
 }
 
 // OpsGenieAlert represents the alert structure for OpsGenie
@@ -26,8 +26,13 @@ type OpsGenieAlert struct {
 	Details     map[string]interface{} `json:"details,omitempty"`
 }
 
+// opsGenieActionRequest is the body of alert actions such as acknowledge and close
+type opsGenieActionRequest struct {
+	Source string `json:"source,omitempty"`
+}
+
 // NewOpsGenieProvider creates a new OpsGenie provider
-func NewOpsGenieProvider(apiKey, endpoint string) *OpsGenieProvider {
+func NewOpsGenieProvider(apiKey, endpoint string, opts ...Option) *OpsGenieProvider {
 	if endpoint == "" {
 		endpoint = "https://api.opsgenie.com/v2/alerts"
 	}
@@ -35,6 +40,7 @@ func NewOpsGenieProvider(apiKey, endpoint string) *OpsGenieProvider {
 	return &OpsGenieProvider{
 		apiKey:   apiKey,
 		endpoint: endpoint,
+		client:   newClient(opts),
 	}
 }
 
@@ -55,31 +61,29 @@ func (p *OpsGenieProvider) SendAlert(ctx context.Context, alert Alert) error {
 		Details:     alert.Details,
 	}
 	
-	data, err := json.Marshal(opsAlert)
-	if err != nil {
-		return fmt.Errorf("failed to marshal alert: %v", err)
-	}
-	
-	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint, bytes.NewBuffer(data))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
-	
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "GenieKey "+p.apiKey)
-	
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send alert: %v", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("request failed with status: %s", resp.Status)
-	}
-	
-	return nil
+	return p.post(ctx, p.endpoint, opsAlert)
+}
+
+// AcknowledgeAlert acknowledges the OpsGenie alert with the alert ID as alias
+func (p *OpsGenieProvider) AcknowledgeAlert(ctx context.Context, id string) error {
+	return p.post(ctx, p.actionURL(id, "acknowledge"), opsGenieActionRequest{Source: "AlertCLI"})
+}
+
+// ResolveAlert closes the OpsGenie alert with the alert ID as alias
+func (p *OpsGenieProvider) ResolveAlert(ctx context.Context, id string) error {
+	return p.post(ctx, p.actionURL(id, "close"), opsGenieActionRequest{Source: "AlertCLI"})
+}
+
+// actionURL builds the URL of an action on the alert with the given alias
+func (p *OpsGenieProvider) actionURL(alias, action string) string {
+	return fmt.Sprintf("%s/%s/%s?identifierType=alias", strings.TrimSuffix(p.endpoint, "/"), url.PathEscape(alias), action)
+}
+
+// post sends a JSON request to the OpsGenie API
+func (p *OpsGenieProvider) post(ctx context.Context, endpoint string, payload interface{}) error {
+	return p.client.postJSON(ctx, endpoint, map[string]string{
+		"Authorization": "GenieKey " + p.apiKey,
+	}, payload)
 }
 
 // mapPriority maps generic priority to OpsGenie priority
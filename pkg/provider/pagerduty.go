@@ -1,12 +1,7 @@
 package provider
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 	"time"
 )
 
@@ -14,13 +9,15 @@ import (
 type PagerDutyProvider struct {
 	apiKey   string
 	endpoint string
+	client   client
 }
 
 // PagerDutyEvent represents the event structure for PagerDuty
 type PagerDutyEvent struct {
-	RoutingKey  string                `json:"routing_key"`
-	EventAction string                `json:"event_action"`
-	Payload     PagerDutyEventPayload `json:"payload"`
+	RoutingKey  string                 `json:"routing_key"`
+	EventAction string                 `json:"event_action"`
+	DedupKey    string                 `json:"dedup_key,omitempty"`
+	Payload     *PagerDutyEventPayload `json:"payload,omitempty"`
 }
 
 // PagerDutyEventPayload represents the event payload for PagerDuty
@@ -36,7 +33,7 @@ type PagerDutyEventPayload struct {
 }
 
 // NewPagerDutyProvider creates a new PagerDuty provider
-func NewPagerDutyProvider(apiKey, endpoint string) *PagerDutyProvider {
+func NewPagerDutyProvider(apiKey, endpoint string, opts ...Option) *PagerDutyProvider {
 	if endpoint == "" {
 		endpoint = "https://events.pagerduty.com/v2/enqueue"
 	}
@@ -44,6 +41,7 @@ func NewPagerDutyProvider(apiKey, endpoint string) *PagerDutyProvider {
 	return &PagerDutyProvider{
 		apiKey:   apiKey,
 		endpoint: endpoint,
+		client:   newClient(opts),
 	}
 }
 
@@ -57,7 +55,8 @@ func (p *PagerDutyProvider) SendAlert(ctx context.Context, alert Alert) error {
 	event := PagerDutyEvent{
 		RoutingKey:  p.apiKey,
 		EventAction: "trigger",
-		Payload: PagerDutyEventPayload{
+		DedupKey:    alert.ID,
+		Payload: &PagerDutyEventPayload{
 			Summary:   alert.Message,
 			Source:    alert.Source,
 			Severity:  mapSeverity(alert.Severity),
@@ -69,34 +68,30 @@ func (p *PagerDutyProvider) SendAlert(ctx context.Context, alert Alert) error {
 		},
 	}
 
-	data, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %v", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint, bytes.NewBuffer(data))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
+	return p.sendEvent(ctx, event)
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send alert: %v", err)
-	}
-	defer resp.Body.Close()
+// AcknowledgeAlert acknowledges the PagerDuty incident with the alert ID as dedup key
+func (p *PagerDutyProvider) AcknowledgeAlert(ctx context.Context, id string) error {
+	return p.sendEvent(ctx, PagerDutyEvent{
+		RoutingKey:  p.apiKey,
+		EventAction: "acknowledge",
+		DedupKey:    id,
+	})
+}
 
-	if resp.StatusCode >= 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response body: %v", err)
-		}
-		return fmt.Errorf("request failed with status: %s, body: %s", resp.Status, body)
-	}
+// ResolveAlert resolves the PagerDuty incident with the alert ID as dedup key
+func (p *PagerDutyProvider) ResolveAlert(ctx context.Context, id string) error {
+	return p.sendEvent(ctx, PagerDutyEvent{
+		RoutingKey:  p.apiKey,
+		EventAction: "resolve",
+		DedupKey:    id,
+	})
+}
 
-	return nil
+// sendEvent sends an event to the PagerDuty Events API
+func (p *PagerDutyProvider) sendEvent(ctx context.Context, event PagerDutyEvent) error {
+	return p.client.postJSON(ctx, p.endpoint, nil, event)
 }
 
 // mapSeverity maps generic severity to PagerDuty severity
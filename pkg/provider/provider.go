@@ -3,6 +3,10 @@ package provider
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -17,23 +21,75 @@ type Alert struct {
 	Timestamp time.Time
 }
 
+// detailKeys returns the keys of alert details in a stable order for display
+func detailKeys(details map[string]interface{}) []string {
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Provider is the interface that all alert providers must implement
 type Provider interface {
 	// SendAlert sends a single alert to the provider
 	SendAlert(ctx context.Context, alert Alert) error
-	
+
+	// AcknowledgeAlert acknowledges a previously sent alert by its ID
+	AcknowledgeAlert(ctx context.Context, id string) error
+
+	// ResolveAlert resolves a previously sent alert by its ID
+	ResolveAlert(ctx context.Context, id string) error
+
 	// Name returns the provider name
 	Name() string
 }
 
 // GetProvider returns a provider implementation based on the name
-func GetProvider(name, apiKey, endpoint string) (Provider, error) {
+func GetProvider(name, apiKey, endpoint string, opts ...Option) (Provider, error) {
 	switch name {
 	case "opsgenie":
-		return NewOpsGenieProvider(apiKey, endpoint), nil
+		return NewOpsGenieProvider(apiKey, endpoint, opts...), nil
 	case "pagerduty":
-		return NewPagerDutyProvider(apiKey, endpoint), nil
+		return NewPagerDutyProvider(apiKey, endpoint, opts...), nil
+	case "slack":
+		// Webhook URLs embed their own secret, there is no default endpoint or API key
+		if endpoint == "" {
+			return nil, fmt.Errorf("slack requires the incoming webhook URL as endpoint")
+		}
+		return NewSlackProvider(endpoint, opts...), nil
+	case "teams":
+		if endpoint == "" {
+			return nil, fmt.Errorf("teams requires the workflow webhook URL as endpoint")
+		}
+		return NewTeamsProvider(endpoint, opts...), nil
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", name)
 	}
 }
+
+// HTTPError is returned when a provider responds with an error status code
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+// Error implements the error interface
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("request failed with status: %s", e.Status)
+	}
+	return fmt.Sprintf("request failed with status: %s, body: %s", e.Status, e.Body)
+}
+
+// newHTTPError builds an HTTPError from an error response, including the start of its body
+func newHTTPError(resp *http.Response) *HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       strings.TrimSpace(string(body)),
+	}
+}
@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// slackMaxFields is the maximum number of fields in a Slack section block
+const slackMaxFields = 10
+
+// SlackProvider implements the Provider interface for Slack incoming webhooks. Slack
+// limits a webhook to about one message per second and answers bursts with 429s, which
+// are retried according to the Retry-After header like for other providers.
+type SlackProvider struct {
+	webhookURL string
+	client     client
+}
+
+// SlackMessage represents an incoming webhook message
+type SlackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []SlackAttachment `json:"attachments,omitempty"`
+}
+
+// SlackAttachment represents a message attachment, used for its colored side bar
+type SlackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+// SlackBlock represents a Block Kit layout block
+type SlackBlock struct {
+	Type     string      `json:"type"`
+	Text     *SlackText  `json:"text,omitempty"`
+	Fields   []SlackText `json:"fields,omitempty"`
+	Elements []SlackText `json:"elements,omitempty"`
+}
+
+// SlackText represents a Block Kit text object
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// NewSlackProvider creates a new Slack provider posting to an incoming webhook URL
+func NewSlackProvider(webhookURL string, opts ...Option) *SlackProvider {
+	return &SlackProvider{
+		webhookURL: webhookURL,
+		client:     newClient(opts),
+	}
+}
+
+// Name returns the provider name
+func (p *SlackProvider) Name() string {
+	return "slack"
+}
+
+// SendAlert posts an alert to Slack as a message colored by severity, with the alert
+// attributes and details as fields
+func (p *SlackProvider) SendAlert(ctx context.Context, alert Alert) error {
+	fields := []SlackText{
+		mrkdwn(fmt.Sprintf("*Severity*\n%s", alert.Severity)),
+		mrkdwn(fmt.Sprintf("*Priority*\n%s", alert.Priority)),
+		mrkdwn(fmt.Sprintf("*Source*\n%s", alert.Source)),
+		mrkdwn(fmt.Sprintf("*Alert ID*\n%s", alert.ID)),
+	}
+
+	for _, k := range detailKeys(alert.Details) {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%v", k, alert.Details[k])))
+	}
+
+	title := fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.Message)
+	blocks := []SlackBlock{{Type: "section", Text: ptr(mrkdwn("*" + title + "*"))}}
+
+	// A section holds a limited number of fields, so long details span several sections
+	for len(fields) > 0 {
+		n := min(len(fields), slackMaxFields)
+		blocks = append(blocks, SlackBlock{Type: "section", Fields: fields[:n]})
+		fields = fields[n:]
+	}
+
+	blocks = append(blocks, SlackBlock{
+		Type:     "context",
+		Elements: []SlackText{mrkdwn("Sent by AlertCLI at " + alert.Timestamp.Format(time.RFC3339))},
+	})
+
+	return p.post(ctx, SlackMessage{
+		Text:        title,
+		Attachments: []SlackAttachment{{Color: slackColor(alert.Severity), Blocks: blocks}},
+	})
+}
+
+// AcknowledgeAlert posts a follow-up message, since webhook messages can't be updated
+func (p *SlackProvider) AcknowledgeAlert(ctx context.Context, id string) error {
+	return p.postUpdate(ctx, fmt.Sprintf(":eyes: Alert %s acknowledged", id), "#439fe0")
+}
+
+// ResolveAlert posts a follow-up message, since webhook messages can't be updated
+func (p *SlackProvider) ResolveAlert(ctx context.Context, id string) error {
+	return p.postUpdate(ctx, fmt.Sprintf(":white_check_mark: Alert %s resolved", id), "#2eb886")
+}
+
+// postUpdate posts a single line status update about an alert
+func (p *SlackProvider) postUpdate(ctx context.Context, text, color string) error {
+	return p.post(ctx, SlackMessage{
+		Text: text,
+		Attachments: []SlackAttachment{{
+			Color:  color,
+			Blocks: []SlackBlock{{Type: "section", Text: ptr(mrkdwn(text))}},
+		}},
+	})
+}
+
+// post sends a message to the incoming webhook
+func (p *SlackProvider) post(ctx context.Context, msg SlackMessage) error {
+	return p.client.postJSON(ctx, p.webhookURL, nil, msg)
+}
+
+// mrkdwn returns a Slack text object formatted with mrkdwn
+func mrkdwn(text string) SlackText {
+	return SlackText{Type: "mrkdwn", Text: text}
+}
+
+// ptr returns a pointer to v
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// slackColor maps generic severity to an attachment color
+func slackColor(severity string) string {
+	switch severity {
+	case "critical":
+		return "#a30200"
+	case "error":
+		return "#e01e5a"
+	case "warning":
+		return "#daa038"
+	case "info":
+		return "#439fe0"
+	default:
+		return "#daa038"
+	}
+}
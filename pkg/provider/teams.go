@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TeamsProvider implements the Provider interface for Microsoft Teams, posting Adaptive
+// Cards to a Teams or Power Automate workflow webhook URL
+type TeamsProvider struct {
+	webhookURL string
+	client     client
+}
+
+// TeamsMessage represents the message accepted by a workflow webhook
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+// TeamsAttachment represents a message attachment holding an Adaptive Card
+type TeamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	ContentURL  *string      `json:"contentUrl"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+// AdaptiveCard represents an Adaptive Card
+type AdaptiveCard struct {
+	Schema  string            `json:"$schema"`
+	Type    string            `json:"type"`
+	Version string            `json:"version"`
+	Body    []CardElement     `json:"body"`
+	MSTeams map[string]string `json:"msteams,omitempty"`
+}
+
+// CardElement represents an Adaptive Card element, such as a TextBlock, Container or FactSet
+type CardElement struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	Size     string        `json:"size,omitempty"`
+	Weight   string        `json:"weight,omitempty"`
+	Color    string        `json:"color,omitempty"`
+	IsSubtle bool          `json:"isSubtle,omitempty"`
+	Wrap     bool          `json:"wrap,omitempty"`
+	Style    string        `json:"style,omitempty"`
+	Bleed    bool          `json:"bleed,omitempty"`
+	Items    []CardElement `json:"items,omitempty"`
+	Facts    []CardFact    `json:"facts,omitempty"`
+}
+
+// CardFact represents a title and value pair of a FactSet
+type CardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// NewTeamsProvider creates a new Teams provider posting to a workflow webhook URL
+func NewTeamsProvider(webhookURL string, opts ...Option) *TeamsProvider {
+	return &TeamsProvider{
+		webhookURL: webhookURL,
+		client:     newClient(opts),
+	}
+}
+
+// Name returns the provider name
+func (p *TeamsProvider) Name() string {
+	return "teams"
+}
+
+// SendAlert posts an alert to Teams as an Adaptive Card with a header colored by
+// severity and the alert attributes and details as facts
+func (p *TeamsProvider) SendAlert(ctx context.Context, alert Alert) error {
+	style, color := teamsStyle(alert.Severity)
+
+	facts := []CardFact{
+		{Title: "Severity", Value: alert.Severity},
+		{Title: "Priority", Value: alert.Priority},
+		{Title: "Source", Value: alert.Source},
+		{Title: "Alert ID", Value: alert.ID},
+	}
+	for _, k := range detailKeys(alert.Details) {
+		facts = append(facts, CardFact{Title: k, Value: fmt.Sprint(alert.Details[k])})
+	}
+
+	return p.post(ctx, []CardElement{
+		{
+			Type:  "Container",
+			Style: style,
+			Bleed: true,
+			Items: []CardElement{
+				{Type: "TextBlock", Text: strings.ToUpper(alert.Severity) + " alert", Weight: "Bolder", Color: color},
+				{Type: "TextBlock", Text: alert.Message, Size: "Large", Weight: "Bolder", Wrap: true},
+			},
+		},
+		{Type: "FactSet", Facts: facts},
+		{Type: "TextBlock", Text: "Sent by AlertCLI at " + alert.Timestamp.Format(time.RFC3339), Size: "Small", IsSubtle: true, Wrap: true},
+	})
+}
+
+// AcknowledgeAlert posts a follow-up card, since posted cards can't be updated
+func (p *TeamsProvider) AcknowledgeAlert(ctx context.Context, id string) error {
+	return p.postUpdate(ctx, fmt.Sprintf("Alert %s acknowledged", id), "accent")
+}
+
+// ResolveAlert posts a follow-up card, since posted cards can't be updated
+func (p *TeamsProvider) ResolveAlert(ctx context.Context, id string) error {
+	return p.postUpdate(ctx, fmt.Sprintf("Alert %s resolved", id), "good")
+}
+
+// postUpdate posts a single line status update about an alert
+func (p *TeamsProvider) postUpdate(ctx context.Context, text, style string) error {
+	return p.post(ctx, []CardElement{{
+		Type:  "Container",
+		Style: style,
+		Bleed: true,
+		Items: []CardElement{{Type: "TextBlock", Text: text, Weight: "Bolder", Wrap: true}},
+	}})
+}
+
+// post sends an Adaptive Card with the given body to the workflow webhook
+func (p *TeamsProvider) post(ctx context.Context, body []CardElement) error {
+	return p.client.postJSON(ctx, p.webhookURL, nil, TeamsMessage{
+		Type: "message",
+		Attachments: []TeamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: AdaptiveCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+				MSTeams: map[string]string{"width": "Full"},
+			},
+		}},
+	})
+}
+
+// teamsStyle maps generic severity to an Adaptive Card container style and text color
+func teamsStyle(severity string) (style, color string) {
+	switch severity {
+	case "critical", "error":
+		return "attention", "Attention"
+	case "warning":
+		return "warning", "Warning"
+	case "info":
+		return "accent", "Accent"
+	default:
+		return "warning", "Warning"
+	}
+}
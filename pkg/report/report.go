@@ -0,0 +1,352 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/generator"
+)
+
+// Formats supported by Write
+const (
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatJUnit = "junit"
+)
+
+// Report is a machine-readable summary of a scenario run
+type Report struct {
+	Scenario   string       `json:"scenario"`
+	Provider   string       `json:"provider"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+	Options    Options      `json:"options"`
+	Sent       int          `json:"sent"`
+	Failed     int          `json:"failed"`
+	Retries    int          `json:"retries"`
+	DurationMs float64      `json:"duration_ms"`
+	Rate       float64      `json:"rate"`
+	AvgLagMs   float64      `json:"avg_lag_ms,omitempty"`
+	MaxLagMs   float64      `json:"max_lag_ms,omitempty"`
+	Dropped    int          `json:"dropped,omitempty"`
+	Latency    Latency      `json:"latency"`
+	Errors     []ErrorClass `json:"errors"`
+	Checks     []Check      `json:"checks,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Options are the scenario options used for the run
+type Options struct {
+	Count       int               `json:"count"`
+	Interval    int               `json:"interval_ms"`
+	Rate        float64           `json:"rate,omitempty"`
+	Arrival     string            `json:"arrival,omitempty"`
+	Concurrency int               `json:"concurrency"`
+	MaxInFlight int               `json:"max_in_flight,omitempty"`
+	Params      map[string]string `json:"params,omitempty"`
+	Stages      []string          `json:"stages,omitempty"`
+	DurationMs  float64           `json:"duration_ms,omitempty"`
+}
+
+// Latency holds latency statistics in milliseconds
+type Latency struct {
+	Count     int64    `json:"count"`
+	MinMs     float64  `json:"min_ms"`
+	MeanMs    float64  `json:"mean_ms"`
+	P50Ms     float64  `json:"p50_ms"`
+	P90Ms     float64  `json:"p90_ms"`
+	P99Ms     float64  `json:"p99_ms"`
+	MaxMs     float64  `json:"max_ms"`
+	Histogram []Bucket `json:"histogram"`
+}
+
+// Bucket is a non-empty latency histogram bucket
+type Bucket struct {
+	FromMs float64 `json:"from_ms"`
+	ToMs   float64 `json:"to_ms"`
+	Count  int64   `json:"count"`
+}
+
+// ErrorClass is the number of failed requests of an error class with sample messages
+type ErrorClass struct {
+	Class   string   `json:"class"`
+	Count   int      `json:"count"`
+	Samples []string `json:"samples"`
+}
+
+// New builds a report of a scenario run. checks are the evaluated thresholds and runErr
+// is the error returned by the scenario, if any.
+func New(scenario, provider string, opts generator.ScenarioOptions, result generator.ScenarioResult, startedAt time.Time, checks []Check, runErr error) Report {
+	r := Report{
+		Scenario:   scenario,
+		Provider:   provider,
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(result.Duration),
+		Options: Options{
+			Count:       opts.Count,
+			Interval:    opts.Interval,
+			Rate:        opts.Rate,
+			Arrival:     opts.Arrival,
+			Concurrency: opts.Concurrency,
+			MaxInFlight: opts.MaxInFlight,
+			Params:      opts.Params,
+			DurationMs:  ms(opts.Duration),
+		},
+		Sent:       result.Sent,
+		Failed:     result.Failed,
+		Retries:    result.Retries,
+		DurationMs: ms(result.Duration),
+		Rate:       result.Rate,
+		AvgLagMs:   ms(result.AvgLag),
+		MaxLagMs:   ms(result.MaxLag),
+		Dropped:    result.Dropped,
+		Errors:     []ErrorClass{},
+		Checks:     checks,
+	}
+
+	for _, s := range opts.Stages {
+		r.Options.Stages = append(r.Options.Stages, s.String())
+	}
+
+	if h := result.Latency; h != nil {
+		r.Latency = Latency{
+			Count:     h.Count(),
+			MinMs:     ms(h.Min()),
+			MeanMs:    ms(h.Mean()),
+			P50Ms:     ms(h.Percentile(50)),
+			P90Ms:     ms(h.Percentile(90)),
+			P99Ms:     ms(h.Percentile(99)),
+			MaxMs:     ms(h.Max()),
+			Histogram: []Bucket{},
+		}
+		for _, b := range h.Buckets() {
+			r.Latency.Histogram = append(r.Latency.Histogram, Bucket{FromMs: ms(b.From), ToMs: ms(b.To), Count: b.Count})
+		}
+	}
+
+	for _, ec := range result.Errors {
+		r.Errors = append(r.Errors, ErrorClass{Class: ec.Class, Count: ec.Count, Samples: ec.Samples})
+	}
+
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+
+	return r
+}
+
+// FormatFromPath guesses the report format from a file extension, defaulting to JSON
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".xml":
+		return FormatJUnit
+	default:
+		return FormatJSON
+	}
+}
+
+// ValidateFormat returns an error if format is not a supported report format
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatCSV, FormatJUnit:
+		return nil
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// WriteFile writes the report to a file in the given format
+func WriteFile(path, format string, r Report) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %v", err)
+	}
+	defer f.Close()
+
+	if err := Write(f, format, r); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the report in the given format
+func Write(w io.Writer, format string, r Report) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, r)
+	case FormatCSV:
+		return writeCSV(w, r)
+	case FormatJUnit:
+		return writeJUnit(w, r)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+// writeJSON writes the report as indented JSON
+func writeJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// writeCSV writes the report as a header and a single row
+func writeCSV(w io.Writer, r Report) error {
+	var errs []string
+	for _, ec := range r.Errors {
+		errs = append(errs, fmt.Sprintf("%s=%d", ec.Class, ec.Count))
+	}
+
+	var params []string
+	for k, v := range r.Options.Params {
+		params = append(params, k+"="+v)
+	}
+	sort.Strings(params)
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"scenario", "provider", "started_at", "finished_at",
+		"count", "interval_ms", "rate_target", "arrival", "concurrency", "params",
+		"sent", "failed", "retries", "duration_ms", "rate",
+		"latency_min_ms", "latency_mean_ms", "latency_p50_ms", "latency_p90_ms", "latency_p99_ms", "latency_max_ms",
+		"errors", "error",
+	})
+	cw.Write([]string{
+		r.Scenario, r.Provider, r.StartedAt.Format(time.RFC3339), r.FinishedAt.Format(time.RFC3339),
+		strconv.Itoa(r.Options.Count), strconv.Itoa(r.Options.Interval), f(r.Options.Rate), r.Options.Arrival,
+		strconv.Itoa(r.Options.Concurrency), strings.Join(params, ";"),
+		strconv.Itoa(r.Sent), strconv.Itoa(r.Failed), strconv.Itoa(r.Retries), f(r.DurationMs), f(r.Rate),
+		f(r.Latency.MinMs), f(r.Latency.MeanMs), f(r.Latency.P50Ms), f(r.Latency.P90Ms), f(r.Latency.P99Ms), f(r.Latency.MaxMs),
+		strings.Join(errs, ";"), r.Error,
+	})
+	cw.Flush()
+	return cw.Error()
+}
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the report as JUnit XML. The run is a test case that fails if the
+// scenario returned an error, or when there are no threshold checks, if any alert failed,
+// although the command then exits successfully. Each threshold check is a test case of
+// its own.
+func writeJUnit(w io.Writer, r Report) error {
+	seconds := strconv.FormatFloat(r.DurationMs/1000, 'f', 3, 64)
+
+	tc := junitTestCase{
+		Name:      r.Scenario,
+		ClassName: "alertcli." + r.Provider,
+		Time:      seconds,
+		SystemOut: fmt.Sprintf("sent=%d failed=%d retries=%d rate=%.2f/s p50=%.3fms p90=%.3fms p99=%.3fms max=%.3fms",
+			r.Sent, r.Failed, r.Retries, r.Rate, r.Latency.P50Ms, r.Latency.P90Ms, r.Latency.P99Ms, r.Latency.MaxMs),
+	}
+	switch {
+	case r.Error != "":
+		tc.Failure = &junitFailure{Message: "scenario failed", Text: r.Error}
+	case r.Failed > 0 && len(r.Checks) == 0:
+		var lines []string
+		for _, ec := range r.Errors {
+			lines = append(lines, fmt.Sprintf("%s: %d %s", ec.Class, ec.Count, strings.Join(ec.Samples, " | ")))
+		}
+		tc.Failure = &junitFailure{
+			Message: fmt.Sprintf("%d of %d alerts failed", r.Failed, r.Sent+r.Failed),
+			Text:    strings.Join(lines, "\n"),
+		}
+	}
+
+	cases := []junitTestCase{tc}
+	for _, c := range r.Checks {
+		check := junitTestCase{
+			Name:      c.Name,
+			ClassName: "alertcli." + r.Provider + ".thresholds",
+			Time:      "0",
+			SystemOut: c.Message,
+		}
+		if !c.Passed {
+			check.Failure = &junitFailure{Message: "threshold violated", Text: c.Message}
+		}
+		cases = append(cases, check)
+	}
+
+	suite := junitTestSuite{
+		Name:      "alertcli.scenario." + r.Scenario,
+		Tests:     len(cases),
+		Time:      seconds,
+		Timestamp: r.StartedAt.Format(time.RFC3339),
+		Properties: []junitProperty{
+			{Name: "provider", Value: r.Provider},
+			{Name: "count", Value: strconv.Itoa(r.Options.Count)},
+			{Name: "concurrency", Value: strconv.Itoa(r.Options.Concurrency)},
+		},
+		Cases: cases,
+	}
+	for _, c := range cases {
+		if c.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ms converts a duration to fractional milliseconds
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
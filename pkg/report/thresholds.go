@@ -0,0 +1,73 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/generator"
+)
+
+// Thresholds are pass/fail criteria for a scenario run. Zero values disable a threshold,
+// except MaxFailureRate which is disabled when negative.
+type Thresholds struct {
+	MaxFailureRate float64
+	MaxP99         time.Duration
+	MinRate        float64
+}
+
+// Check is the outcome of evaluating a threshold
+type Check struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// Evaluate checks a scenario result against the enabled thresholds
+func (t Thresholds) Evaluate(result generator.ScenarioResult) []Check {
+	var checks []Check
+
+	if t.MaxFailureRate >= 0 {
+		var rate float64
+		if total := result.Sent + result.Failed; total > 0 {
+			rate = float64(result.Failed) / float64(total)
+		}
+		checks = append(checks, Check{
+			Name:    "max-failure-rate",
+			Passed:  rate <= t.MaxFailureRate,
+			Message: fmt.Sprintf("failure rate %.4f (max %.4f)", rate, t.MaxFailureRate),
+		})
+	}
+
+	if t.MaxP99 > 0 {
+		var p99 time.Duration
+		if result.Latency != nil {
+			p99 = result.Latency.Percentile(99)
+		}
+		checks = append(checks, Check{
+			Name:    "max-p99",
+			Passed:  p99 <= t.MaxP99,
+			Message: fmt.Sprintf("p99 latency %v (max %v)", p99, t.MaxP99),
+		})
+	}
+
+	if t.MinRate > 0 {
+		checks = append(checks, Check{
+			Name:    "min-rate",
+			Passed:  result.Rate >= t.MinRate,
+			Message: fmt.Sprintf("rate %.2f alerts/sec (min %.2f)", result.Rate, t.MinRate),
+		})
+	}
+
+	return checks
+}
+
+// Failed returns the checks that did not pass
+func Failed(checks []Check) []Check {
+	var failed []Check
+	for _, c := range checks {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/generator"
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// generatorTarget identifies the provider that generated alerts are sent to
+type generatorTarget struct {
+	Provider string `json:"provider"`
+	APIKey   string `json:"api_key"`
+	Endpoint string `json:"endpoint"`
+	Retries  int    `json:"retries"`
+}
+
+// newProvider returns the provider described by the target
+func (t generatorTarget) newProvider() (provider.Provider, error) {
+	policy := provider.DefaultRetryPolicy
+	policy.MaxRetries = t.Retries
+	return provider.GetProvider(t.Provider, t.APIKey, t.Endpoint, provider.WithRetryPolicy(policy))
+}
+
+// runScenarioRequest is the body accepted by the run scenario endpoint
+type runScenarioRequest struct {
+	generatorTarget
+	Count       int               `json:"count"`
+	Interval    int               `json:"interval"`
+	Rate        float64           `json:"rate"`
+	Arrival     string            `json:"arrival"`
+	Concurrency int               `json:"concurrency"`
+	MaxInFlight int               `json:"max_in_flight"`
+	Params      map[string]string `json:"params"`
+	Stages      string            `json:"stages"`
+	Duration    string            `json:"duration"`
+}
+
+// generateAlertRequest is the body accepted by the generate alert endpoint
+type generateAlertRequest struct {
+	generatorTarget
+	Message  string                 `json:"message"`
+	Severity string                 `json:"severity"`
+	Priority string                 `json:"priority"`
+	Source   string                 `json:"source"`
+	Details  map[string]interface{} `json:"details"`
+}
+
+// scenarioResponse is the JSON representation of a scenario result
+type scenarioResponse struct {
+	Scenario string  `json:"scenario"`
+	Sent     int     `json:"sent"`
+	Failed   int     `json:"failed"`
+	Retries  int     `json:"retries"`
+	Duration string  `json:"duration"`
+	Rate     float64 `json:"rate"`
+	P50      string  `json:"p50"`
+	P90      string  `json:"p90"`
+	P99      string  `json:"p99"`
+	Max      string  `json:"max"`
+
+	Errors map[string]int `json:"errors,omitempty"`
+}
+
+// handleRunScenario runs a predefined scenario against the requested provider
+func (s *Server) handleRunScenario(w http.ResponseWriter, r *http.Request) {
+	req := runScenarioRequest{
+		Count:       100,
+		Interval:    100,
+		Concurrency: 10,
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+
+	p, err := req.newProvider()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to initialize provider: %v", err))
+		return
+	}
+
+	var stages []generator.Stage
+	if req.Stages != "" {
+		stages, err = generator.ParseStages(req.Stages)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid duration: %v", err))
+			return
+		}
+	}
+
+	name := r.PathValue("scenario")
+	gen := generator.NewGenerator(p)
+	result, err := gen.RunScenario(r.Context(), name, generator.ScenarioOptions{
+		Count:       req.Count,
+		Interval:    req.Interval,
+		Rate:        req.Rate,
+		Arrival:     req.Arrival,
+		Concurrency: req.Concurrency,
+		MaxInFlight: req.MaxInFlight,
+		Params:      req.Params,
+		Stages:      stages,
+		Duration:    duration,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("scenario failed: %v", err))
+		return
+	}
+
+	errs := make(map[string]int)
+	for _, ec := range result.Errors {
+		errs[ec.Class] = ec.Count
+	}
+
+	writeJSON(w, http.StatusOK, scenarioResponse{
+		Scenario: name,
+		Sent:     result.Sent,
+		Failed:   result.Failed,
+		Retries:  result.Retries,
+		Duration: result.Duration.String(),
+		Rate:     result.Rate,
+		P50:      result.Latency.Percentile(50).String(),
+		P90:      result.Latency.Percentile(90).String(),
+		P99:      result.Latency.Percentile(99).String(),
+		Max:      result.Latency.Max().String(),
+		Errors:   errs,
+	})
+}
+
+// handleGenerateAlert sends a single alert to the requested provider
+func (s *Server) handleGenerateAlert(w http.ResponseWriter, r *http.Request) {
+	req := generateAlertRequest{
+		Message:  "Test alert",
+		Severity: "warning",
+		Priority: "medium",
+		Source:   "alertcli",
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+
+	p, err := req.newProvider()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to initialize provider: %v", err))
+		return
+	}
+
+	alert := provider.Alert{
+		ID:        fmt.Sprintf("alert-%d", time.Now().UnixNano()),
+		Message:   req.Message,
+		Severity:  req.Severity,
+		Source:    req.Source,
+		Priority:  req.Priority,
+		Details:   req.Details,
+		Timestamp: time.Now(),
+	}
+
+	if err := p.SendAlert(r.Context(), alert); err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("failed to send alert: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": alert.ID, "provider": p.Name()})
+}
+
+// handleListScenarios lists the available scenarios
+func (s *Server) handleListScenarios(w http.ResponseWriter, r *http.Request) {
+	type scenarioInfo struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	result := []scenarioInfo{}
+	for _, sc := range generator.ListScenarios() {
+		result = append(result, scenarioInfo{Name: sc.Name, Description: sc.Description})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"scenarios": result})
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// handleCreateAlert creates a new OpsGenie alert
+func (s *Server) handleCreateAlert(w http.ResponseWriter, r *http.Request) {
+	var req provider.OpsGenieAlert
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+
+	if req.Message == "" {
+		writeError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+
+	inc, _ := s.store.Trigger(Incident{
+		Provider: "opsgenie",
+		DedupKey: req.Alias,
+		Message:  req.Message,
+		Priority: req.Priority,
+		Source:   req.Source,
+		Details:  req.Details,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": inc})
+}
+
+// handleListAlerts lists all OpsGenie alerts
+func (s *Server) handleListAlerts(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": s.store.List("opsgenie")})
+}
+
+// handleAcknowledgeAlert acknowledges an OpsGenie alert
+func (s *Server) handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	s.setAlertStatus(w, r, StatusAcknowledged)
+}
+
+// handleCloseAlert closes an OpsGenie alert
+func (s *Server) handleCloseAlert(w http.ResponseWriter, r *http.Request) {
+	s.setAlertStatus(w, r, StatusResolved)
+}
+
+// setAlertStatus transitions an OpsGenie alert identified by ID or alias to the given status
+func (s *Server) setAlertStatus(w http.ResponseWriter, r *http.Request, status string) {
+	var (
+		inc Incident
+		err error
+	)
+	if r.URL.Query().Get("identifierType") == "alias" {
+		inc, err = s.store.TransitionByDedupKey("opsgenie", r.PathValue("id"), status)
+	} else {
+		inc, err = s.store.Transition("opsgenie", r.PathValue("id"), status)
+	}
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": inc})
+}
+
+// opsGenieRequest records the processing status of an asynchronous OpsGenie request
+type opsGenieRequest struct {
+	Success     bool      `json:"success"`
+	Action      string    `json:"action"`
+	ProcessedAt time.Time `json:"processedAt"`
+	IsSuccess   bool      `json:"isSuccess"`
+	Status      string    `json:"status"`
+	AlertID     string    `json:"alertId,omitempty"`
+	Alias       string    `json:"alias,omitempty"`
+}
+
+// maxTrackedRequests is the number of latest OpsGenie requests whose status is kept
+const maxTrackedRequests = 10000
+
+// requestTracker keeps the status of the latest OpsGenie requests so clients can poll them
+type requestTracker struct {
+	mu       sync.Mutex
+	limit    int
+	requests map[string]opsGenieRequest
+	order    []string // request IDs, oldest first
+}
+
+// newRequestTracker creates a new empty request tracker
+func newRequestTracker() *requestTracker {
+	return &requestTracker{
+		limit:    maxTrackedRequests,
+		requests: make(map[string]opsGenieRequest),
+	}
+}
+
+// record stores the status of a processed request, forgetting the oldest request once
+// the limit is reached
+func (t *requestTracker) record(id string, req opsGenieRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.requests[id]; !ok {
+		t.order = append(t.order, id)
+		if len(t.order) > t.limit {
+			delete(t.requests, t.order[0])
+			t.order = t.order[1:]
+		}
+	}
+	t.requests[id] = req
+}
+
+// get returns the status of a request
+func (t *requestTracker) get(id string) (opsGenieRequest, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	req, ok := t.requests[id]
+	return req, ok
+}
+
+// handleCreateAlertV2 accepts alerts in the OpsGenie Alert API v2 format
+func (s *Server) handleCreateAlertV2(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	requestID := newRequestID()
+
+	if !checkGenieKey(r) {
+		writeOpsGenieError(w, http.StatusUnauthorized, "Key format is not valid!", nil, start, requestID)
+		return
+	}
+
+	var req provider.OpsGenieAlert
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpsGenieError(w, http.StatusBadRequest, "Could not parse request body: "+err.Error(), nil, start, requestID)
+		return
+	}
+
+	if errs := validateOpsGenieAlert(req); len(errs) > 0 {
+		writeOpsGenieError(w, http.StatusUnprocessableEntity, "Request body is not processable. Please check the errors.", errs, start, requestID)
+		return
+	}
+
+	inc, deduplicated := s.store.Trigger(Incident{
+		Provider: "opsgenie",
+		DedupKey: req.Alias,
+		Message:  req.Message,
+		Priority: req.Priority,
+		Source:   req.Source,
+		Details:  req.Details,
+	})
+
+	status := "Created alert"
+	if deduplicated {
+		status = "Alert is deduplicated"
+	}
+
+	s.requests.record(requestID, opsGenieRequest{
+		Success:     true,
+		Action:      "Create",
+		ProcessedAt: time.Now(),
+		IsSuccess:   true,
+		Status:      status,
+		AlertID:     inc.ID,
+		Alias:       req.Alias,
+	})
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"result":    "Request will be processed",
+		"took":      time.Since(start).Seconds(),
+		"requestId": requestID,
+	})
+}
+
+// handleAcknowledgeAlertV2 acknowledges an alert through the OpsGenie Alert API v2
+func (s *Server) handleAcknowledgeAlertV2(w http.ResponseWriter, r *http.Request) {
+	s.transitionAlertV2(w, r, "Acknowledge", StatusAcknowledged)
+}
+
+// handleCloseAlertV2 closes an alert through the OpsGenie Alert API v2
+func (s *Server) handleCloseAlertV2(w http.ResponseWriter, r *http.Request) {
+	s.transitionAlertV2(w, r, "Close", StatusResolved)
+}
+
+// transitionAlertV2 moves an alert identified by ID or alias to a new status. Like the
+// real API the request is accepted right away and its outcome is exposed through the
+// request status endpoint.
+func (s *Server) transitionAlertV2(w http.ResponseWriter, r *http.Request, action, status string) {
+	start := time.Now()
+	requestID := newRequestID()
+
+	if !checkGenieKey(r) {
+		writeOpsGenieError(w, http.StatusUnauthorized, "Key format is not valid!", nil, start, requestID)
+		return
+	}
+
+	identifier := r.PathValue("identifier")
+	var (
+		inc Incident
+		err error
+	)
+	switch r.URL.Query().Get("identifierType") {
+	case "", "id":
+		inc, err = s.store.Transition("opsgenie", identifier, status)
+	case "alias":
+		inc, err = s.store.TransitionByDedupKey("opsgenie", identifier, status)
+	default:
+		writeOpsGenieError(w, http.StatusUnprocessableEntity, "Request is not processable. Please check the errors.",
+			map[string]string{"identifierType": "identifierType should be one of [id, alias]."}, start, requestID)
+		return
+	}
+
+	req := opsGenieRequest{
+		Success:     err == nil,
+		Action:      action,
+		ProcessedAt: time.Now(),
+		IsSuccess:   err == nil,
+		Status:      action + " alert",
+		AlertID:     inc.ID,
+		Alias:       inc.DedupKey,
+	}
+	switch {
+	case errors.Is(err, ErrNotFound):
+		req.Status = "Alert does not exist"
+	case err != nil:
+		req.Status = err.Error()
+	}
+	s.requests.record(requestID, req)
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"result":    "Request will be processed",
+		"took":      time.Since(start).Seconds(),
+		"requestId": requestID,
+	})
+}
+
+// handleGetRequestStatus returns the processing status of an OpsGenie request
+func (s *Server) handleGetRequestStatus(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	requestID := newRequestID()
+
+	if !checkGenieKey(r) {
+		writeOpsGenieError(w, http.StatusUnauthorized, "Key format is not valid!", nil, start, requestID)
+		return
+	}
+
+	req, ok := s.requests.get(r.PathValue("requestId"))
+	if !ok {
+		writeOpsGenieError(w, http.StatusNotFound, "Request not found. It might not be processed, yet.", nil, start, requestID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":      req,
+		"took":      time.Since(start).Seconds(),
+		"requestId": requestID,
+	})
+}
+
+// checkGenieKey reports whether the request carries a GenieKey Authorization header
+func checkGenieKey(r *http.Request) bool {
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GenieKey ")
+	return ok && strings.TrimSpace(key) != ""
+}
+
+// validateOpsGenieAlert returns the validation errors of an OpsGenie alert keyed by field
+func validateOpsGenieAlert(alert provider.OpsGenieAlert) map[string]string {
+	errs := make(map[string]string)
+	if strings.TrimSpace(alert.Message) == "" {
+		errs["message"] = "Message can not be empty."
+	} else if len(alert.Message) > 130 {
+		errs["message"] = "Message can not be longer than 130 characters."
+	}
+
+	switch alert.Priority {
+	case "", "P1", "P2", "P3", "P4", "P5":
+	default:
+		errs["priority"] = "Priority should be one of [P1, P2, P3, P4, P5]."
+	}
+
+	if len(alert.Alias) > 512 {
+		errs["alias"] = "Alias can not be longer than 512 characters."
+	}
+
+	return errs
+}
+
+// writeOpsGenieError writes an error in the OpsGenie response envelope
+func writeOpsGenieError(w http.ResponseWriter, status int, message string, errs map[string]string, start time.Time, requestID string) {
+	body := map[string]interface{}{
+		"message":   message,
+		"took":      time.Since(start).Seconds(),
+		"requestId": requestID,
+	}
+	if len(errs) > 0 {
+		body["errors"] = errs
+	}
+
+	writeJSON(w, status, body)
+}
+
+// newRequestID returns a random UUID like the ones OpsGenie uses for request IDs
+func newRequestID() string {
+	id := randomHex(16)
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
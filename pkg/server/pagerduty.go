@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+)
+
+// pagerDutyIncident is the incident body accepted by the PagerDuty-compatible endpoints
+type pagerDutyIncident struct {
+	Title       string                 `json:"title"`
+	IncidentKey string                 `json:"incident_key,omitempty"`
+	Urgency     string                 `json:"urgency,omitempty"`
+	Status      string                 `json:"status,omitempty"`
+	Source      string                 `json:"source,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+// pagerDutyIncidentRequest wraps an incident the way the PagerDuty REST API does
+type pagerDutyIncidentRequest struct {
+	Incident pagerDutyIncident `json:"incident"`
+}
+
+// handleCreateIncident creates a new PagerDuty incident
+func (s *Server) handleCreateIncident(w http.ResponseWriter, r *http.Request) {
+	var req pagerDutyIncidentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+
+	if req.Incident.Title == "" {
+		writeError(w, http.StatusBadRequest, "incident title is required")
+		return
+	}
+
+	inc, _ := s.store.Trigger(Incident{
+		Provider: "pagerduty",
+		DedupKey: req.Incident.IncidentKey,
+		Message:  req.Incident.Title,
+		Priority: req.Incident.Urgency,
+		Source:   req.Incident.Source,
+		Details:  req.Incident.Details,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"incident": inc})
+}
+
+// handleUpdateIncident updates the status of an existing PagerDuty incident
+func (s *Server) handleUpdateIncident(w http.ResponseWriter, r *http.Request) {
+	var req pagerDutyIncidentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+
+	inc, err := s.store.Update("pagerduty", r.PathValue("id"), func(inc *Incident) error {
+		if req.Incident.Status != "" {
+			if err := inc.transition(req.Incident.Status); err != nil {
+				return err
+			}
+		}
+		if req.Incident.Title != "" {
+			inc.Message = req.Incident.Title
+		}
+		if req.Incident.Urgency != "" {
+			inc.Priority = req.Incident.Urgency
+		}
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"incident": inc})
+}
+
+// handleListIncidents lists all PagerDuty incidents
+func (s *Server) handleListIncidents(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"incidents": s.store.List("pagerduty")})
+}
+
+// pagerDutyEventResponse is the response body of the PagerDuty Events API v2
+type pagerDutyEventResponse struct {
+	Status   string   `json:"status"`
+	Message  string   `json:"message"`
+	DedupKey string   `json:"dedup_key,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// handleEnqueueEvent accepts events in the PagerDuty Events API v2 format
+func (s *Server) handleEnqueueEvent(w http.ResponseWriter, r *http.Request) {
+	var event provider.PagerDutyEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeJSON(w, http.StatusBadRequest, pagerDutyEventResponse{
+			Status:  "invalid event",
+			Message: "Event object is invalid",
+			Errors:  []string{"Invalid JSON: " + err.Error()},
+		})
+		return
+	}
+
+	if errs := validatePagerDutyEvent(event); len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, pagerDutyEventResponse{
+			Status:  "invalid event",
+			Message: "Event object is invalid",
+			Errors:  errs,
+		})
+		return
+	}
+
+	switch event.EventAction {
+	case "trigger":
+		if event.DedupKey == "" {
+			event.DedupKey = randomHex(16)
+		}
+		s.store.Trigger(Incident{
+			Provider: "pagerduty",
+			DedupKey: event.DedupKey,
+			Message:  event.Payload.Summary,
+			Severity: event.Payload.Severity,
+			Source:   event.Payload.Source,
+			Details:  event.Payload.Details,
+		})
+	case "acknowledge":
+		// Like the real API, events for unknown or closed incidents are silently dropped
+		s.store.TransitionByDedupKey("pagerduty", event.DedupKey, StatusAcknowledged)
+	case "resolve":
+		s.store.TransitionByDedupKey("pagerduty", event.DedupKey, StatusResolved)
+	}
+
+	writeJSON(w, http.StatusAccepted, pagerDutyEventResponse{
+		Status:   "success",
+		Message:  "Event processed",
+		DedupKey: event.DedupKey,
+	})
+}
+
+// validatePagerDutyEvent returns the validation errors of a PagerDuty event
+func validatePagerDutyEvent(event provider.PagerDutyEvent) []string {
+	var errs []string
+	if event.RoutingKey == "" {
+		errs = append(errs, "'routing_key' is missing or blank")
+	}
+
+	switch event.EventAction {
+	case "trigger":
+		if event.Payload == nil {
+			errs = append(errs, "'payload' is missing")
+			break
+		}
+		if event.Payload.Summary == "" {
+			errs = append(errs, "'payload.summary' is missing or blank")
+		}
+		if event.Payload.Source == "" {
+			errs = append(errs, "'payload.source' is missing or blank")
+		}
+		switch event.Payload.Severity {
+		case "critical", "error", "warning", "info":
+		case "":
+			errs = append(errs, "'payload.severity' is missing or blank")
+		default:
+			errs = append(errs, "'payload.severity' must be one of critical, error, warning, info")
+		}
+	case "acknowledge", "resolve":
+		if event.DedupKey == "" {
+			errs = append(errs, "'dedup_key' is missing or blank")
+		}
+	case "":
+		errs = append(errs, "'event_action' is missing or blank")
+	default:
+		errs = append(errs, "'event_action' must be one of trigger, acknowledge, resolve")
+	}
+
+	return errs
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Server is a fake alert backend that mimics the APIs of alert management providers
+type Server struct {
+	mux      *http.ServeMux
+	store    *Store
+	requests *requestTracker
+}
+
+// NewServer creates a new fake backend server with all routes registered
+func NewServer() *Server {
+	s := &Server{
+		mux:      http.NewServeMux(),
+		store:    NewStore(),
+		requests: newRequestTracker(),
+	}
+
+	s.routes()
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// routes registers all handlers on the server mux
+func (s *Server) routes() {
+	// PagerDuty-compatible endpoints
+	s.mux.HandleFunc("POST /pagerduty/v1/incidents", s.handleCreateIncident)
+	s.mux.HandleFunc("PUT /pagerduty/v1/incidents/{id}", s.handleUpdateIncident)
+	s.mux.HandleFunc("GET /pagerduty/v1/incidents", s.handleListIncidents)
+
+	// PagerDuty Events API v2 compatible endpoints
+	s.mux.HandleFunc("POST /v2/enqueue", s.handleEnqueueEvent)
+
+	// OpsGenie-compatible endpoints
+	s.mux.HandleFunc("POST /opsgenie/v1/alerts", s.handleCreateAlert)
+	s.mux.HandleFunc("GET /opsgenie/v1/alerts", s.handleListAlerts)
+	s.mux.HandleFunc("POST /opsgenie/v1/alerts/{id}/acknowledge", s.handleAcknowledgeAlert)
+	s.mux.HandleFunc("POST /opsgenie/v1/alerts/{id}/close", s.handleCloseAlert)
+
+	// OpsGenie Alert API v2 compatible endpoints
+	s.mux.HandleFunc("POST /v2/alerts", s.handleCreateAlertV2)
+	s.mux.HandleFunc("POST /v2/alerts/{identifier}/acknowledge", s.handleAcknowledgeAlertV2)
+	s.mux.HandleFunc("POST /v2/alerts/{identifier}/close", s.handleCloseAlertV2)
+	s.mux.HandleFunc("GET /v2/alerts/requests/{requestId}", s.handleGetRequestStatus)
+
+	// Alert generator control endpoints
+	s.mux.HandleFunc("POST /generator/run/{scenario}", s.handleRunScenario)
+	s.mux.HandleFunc("POST /generator/alert", s.handleGenerateAlert)
+	s.mux.HandleFunc("GET /generator/scenarios", s.handleListScenarios)
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response with the given status code
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeStoreError writes the response matching an error returned by the store
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidTransition):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// randomHex returns a random hex string of n bytes
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
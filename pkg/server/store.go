@@ -0,0 +1,203 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Incident statuses
+const (
+	StatusTriggered    = "triggered"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
+
+var (
+	// ErrNotFound is returned when an incident does not exist
+	ErrNotFound = errors.New("incident not found")
+
+	// ErrInvalidTransition is returned when an incident cannot move to the requested status
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+// Incident represents an incident or alert received by the fake backend
+type Incident struct {
+	ID             string                 `json:"id"`
+	Provider       string                 `json:"provider"`
+	DedupKey       string                 `json:"dedup_key,omitempty"`
+	Message        string                 `json:"message"`
+	Severity       string                 `json:"severity,omitempty"`
+	Priority       string                 `json:"priority,omitempty"`
+	Source         string                 `json:"source,omitempty"`
+	Status         string                 `json:"status"`
+	Count          int                    `json:"count"`
+	Details        map[string]interface{} `json:"details,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+	AcknowledgedAt *time.Time             `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time             `json:"resolved_at,omitempty"`
+}
+
+// transition moves the incident to the given status if the state machine allows it.
+// Incidents go from triggered to acknowledged and/or resolved; repeating the current
+// acknowledged or resolved status is a no-op.
+func (inc *Incident) transition(status string) error {
+	now := time.Now()
+
+	switch {
+	case status == inc.Status && status != StatusTriggered:
+		return nil
+	case status == StatusAcknowledged && inc.Status == StatusTriggered:
+		inc.AcknowledgedAt = &now
+	case status == StatusResolved && inc.Status != StatusResolved:
+		inc.ResolvedAt = &now
+	default:
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, inc.Status, status)
+	}
+
+	inc.Status = status
+	return nil
+}
+
+// maxIncidents is the number of latest incidents kept by a store
+const maxIncidents = 10000
+
+// Store is an in-memory, concurrency-safe store of the latest incidents
+type Store struct {
+	mu        sync.Mutex
+	nextID    int
+	limit     int
+	incidents map[string]*Incident
+	dedup     map[string]string
+	order     []string
+}
+
+// NewStore creates a new empty store
+func NewStore() *Store {
+	return &Store{
+		limit:     maxIncidents,
+		incidents: make(map[string]*Incident),
+		dedup:     make(map[string]string),
+	}
+}
+
+// Trigger records a triggered incident. If an open incident of the same provider
+// already has the dedup key, the trigger is merged into it by increasing its count
+// and the second return value is true. Otherwise a new incident is created.
+func (s *Store) Trigger(inc Incident) (Incident, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if inc.DedupKey != "" {
+		if id, ok := s.dedup[dedupIndex(inc.Provider, inc.DedupKey)]; ok {
+			existing := s.incidents[id]
+			if existing.Status != StatusResolved {
+				existing.Count++
+				existing.UpdatedAt = now
+				return *existing, true
+			}
+		}
+	}
+
+	s.nextID++
+	inc.ID = fmt.Sprintf("%s-%d", inc.Provider, s.nextID)
+	inc.Status = StatusTriggered
+	inc.Count = 1
+	inc.CreatedAt = now
+	inc.UpdatedAt = now
+
+	s.incidents[inc.ID] = &inc
+	s.order = append(s.order, inc.ID)
+	if inc.DedupKey != "" {
+		s.dedup[dedupIndex(inc.Provider, inc.DedupKey)] = inc.ID
+	}
+	if len(s.order) > s.limit {
+		s.evictOldest()
+	}
+	return inc, false
+}
+
+// evictOldest forgets the oldest incident, so a long-running backend has bounded memory
+func (s *Store) evictOldest() {
+	oldest := s.incidents[s.order[0]]
+	delete(s.incidents, oldest.ID)
+	if key := dedupIndex(oldest.Provider, oldest.DedupKey); oldest.DedupKey != "" && s.dedup[key] == oldest.ID {
+		delete(s.dedup, key)
+	}
+	s.order = s.order[1:]
+}
+
+// Get returns the incident of a provider with the given ID
+func (s *Store) Get(provider, id string) (Incident, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc, ok := s.incidents[id]
+	if !ok || inc.Provider != provider {
+		return Incident{}, false
+	}
+	return *inc, true
+}
+
+// Update applies fn to the incident of a provider with the given ID and returns the result.
+// If fn returns an error the incident is left unchanged.
+func (s *Store) Update(provider, id string, fn func(*Incident) error) (Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inc, ok := s.incidents[id]
+	if !ok || inc.Provider != provider {
+		return Incident{}, ErrNotFound
+	}
+
+	updated := *inc
+	if err := fn(&updated); err != nil {
+		return *inc, err
+	}
+
+	updated.UpdatedAt = time.Now()
+	*inc = updated
+	return updated, nil
+}
+
+// Transition moves the incident of a provider with the given ID to a new status
+func (s *Store) Transition(provider, id, status string) (Incident, error) {
+	return s.Update(provider, id, func(inc *Incident) error {
+		return inc.transition(status)
+	})
+}
+
+// TransitionByDedupKey moves the latest incident of a provider with the given dedup key to a new status
+func (s *Store) TransitionByDedupKey(provider, key, status string) (Incident, error) {
+	s.mu.Lock()
+	id, ok := s.dedup[dedupIndex(provider, key)]
+	s.mu.Unlock()
+	if !ok {
+		return Incident{}, ErrNotFound
+	}
+
+	return s.Transition(provider, id, status)
+}
+
+// List returns all incidents for a provider in creation order
+func (s *Store) List(provider string) []Incident {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []Incident{}
+	for _, id := range s.order {
+		inc := s.incidents[id]
+		if inc.Provider == provider {
+			result = append(result, *inc)
+		}
+	}
+	return result
+}
+
+// dedupIndex builds the key used to index incidents by provider and dedup key
+func dedupIndex(provider, key string) string {
+	return provider + "/" + key
+}
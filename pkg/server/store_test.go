@@ -0,0 +1,411 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIncidentTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantErr  bool
+	}{
+		{StatusTriggered, StatusAcknowledged, false},
+		{StatusTriggered, StatusResolved, false},
+		{StatusAcknowledged, StatusResolved, false},
+		{StatusAcknowledged, StatusAcknowledged, false},
+		{StatusResolved, StatusResolved, false},
+		{StatusTriggered, StatusTriggered, true},
+		{StatusAcknowledged, StatusTriggered, true},
+		{StatusResolved, StatusAcknowledged, true},
+		{StatusResolved, StatusTriggered, true},
+		{StatusTriggered, "snoozed", true},
+	}
+
+	for _, tt := range tests {
+		inc := &Incident{Status: tt.from}
+		err := inc.transition(tt.to)
+
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("%s -> %s: got error %v, want ErrInvalidTransition", tt.from, tt.to, err)
+			}
+			if inc.Status != tt.from {
+				t.Errorf("%s -> %s: status changed to %s", tt.from, tt.to, inc.Status)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s -> %s: unexpected error: %v", tt.from, tt.to, err)
+			continue
+		}
+		if inc.Status != tt.to {
+			t.Errorf("%s -> %s: status is %s", tt.from, tt.to, inc.Status)
+		}
+		if tt.to == StatusAcknowledged && tt.from != tt.to && inc.AcknowledgedAt == nil {
+			t.Errorf("%s -> %s: acknowledged time not set", tt.from, tt.to)
+		}
+		if tt.to == StatusResolved && tt.from != tt.to && inc.ResolvedAt == nil {
+			t.Errorf("%s -> %s: resolved time not set", tt.from, tt.to)
+		}
+	}
+}
+
+func TestStoreLifecycle(t *testing.T) {
+	s := NewStore()
+	first, _ := s.Trigger(Incident{Provider: "opsgenie", DedupKey: "disk-full", Message: "Disk full"})
+
+	steps := []struct {
+		name       string
+		do         func() (Incident, bool, error)
+		wantStatus string
+		wantCount  int
+		wantMerged bool
+		wantNew    bool // whether the incident differs from the first one
+	}{
+		{
+			name:       "merge into triggered",
+			do:         trigger(s, "opsgenie", "disk-full"),
+			wantStatus: StatusTriggered,
+			wantCount:  2,
+			wantMerged: true,
+		},
+		{
+			name:       "acknowledge",
+			do:         transition(s, first.ID, StatusAcknowledged),
+			wantStatus: StatusAcknowledged,
+			wantCount:  2,
+		},
+		{
+			name:       "merge into acknowledged",
+			do:         trigger(s, "opsgenie", "disk-full"),
+			wantStatus: StatusAcknowledged,
+			wantCount:  3,
+			wantMerged: true,
+		},
+		{
+			name:       "resolve",
+			do:         transition(s, first.ID, StatusResolved),
+			wantStatus: StatusResolved,
+			wantCount:  3,
+		},
+		{
+			name:       "re-trigger after resolve",
+			do:         trigger(s, "opsgenie", "disk-full"),
+			wantStatus: StatusTriggered,
+			wantCount:  1,
+			wantNew:    true,
+		},
+		{
+			name:       "same key of another provider",
+			do:         trigger(s, "pagerduty", "disk-full"),
+			wantStatus: StatusTriggered,
+			wantCount:  1,
+			wantNew:    true,
+		},
+	}
+
+	for _, step := range steps {
+		inc, merged, err := step.do()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if inc.Status != step.wantStatus {
+			t.Errorf("%s: status is %s, want %s", step.name, inc.Status, step.wantStatus)
+		}
+		if inc.Count != step.wantCount {
+			t.Errorf("%s: count is %d, want %d", step.name, inc.Count, step.wantCount)
+		}
+		if merged != step.wantMerged {
+			t.Errorf("%s: merged is %v, want %v", step.name, merged, step.wantMerged)
+		}
+		if isNew := inc.ID != first.ID; isNew != step.wantNew {
+			t.Errorf("%s: incident %s, first incident %s", step.name, inc.ID, first.ID)
+		}
+	}
+
+	if got := len(s.List("opsgenie")); got != 2 {
+		t.Errorf("store has %d opsgenie incidents, want 2", got)
+	}
+
+	// The dedup key now points at the re-triggered incident
+	inc, err := s.TransitionByDedupKey("opsgenie", "disk-full", StatusAcknowledged)
+	if err != nil || inc.ID == first.ID {
+		t.Errorf("transition by dedup key: got %s, %v, want the re-triggered incident", inc.ID, err)
+	}
+}
+
+func TestStoreTransitionErrors(t *testing.T) {
+	s := NewStore()
+	resolved, _ := s.Trigger(Incident{Provider: "opsgenie", Message: "Resolved"})
+	if _, err := s.Transition("opsgenie", resolved.ID, StatusResolved); err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		provider, id, to string
+		wantErr          error
+	}{
+		{"unknown incident", "opsgenie", "opsgenie-42", StatusAcknowledged, ErrNotFound},
+		{"other provider", "pagerduty", resolved.ID, StatusAcknowledged, ErrNotFound},
+		{"acknowledge resolved", "opsgenie", resolved.ID, StatusAcknowledged, ErrInvalidTransition},
+		{"re-trigger resolved", "opsgenie", resolved.ID, StatusTriggered, ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		if _, err := s.Transition(tt.provider, tt.id, tt.to); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if inc, _ := s.Get("opsgenie", resolved.ID); inc.Status != StatusResolved || inc.AcknowledgedAt != nil {
+		t.Errorf("failed transitions changed the incident: %+v", inc)
+	}
+
+	if _, err := s.TransitionByDedupKey("opsgenie", "unknown", StatusResolved); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown dedup key: got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestInvalidTransitionConflict(t *testing.T) {
+	srv := NewServer()
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/opsgenie/v1/alerts", strings.NewReader(`{"message":"Disk full"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, want %d", rec.Code, http.StatusCreated)
+	}
+	var created struct {
+		Data Incident `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("create: invalid response: %v", err)
+	}
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/opsgenie/v1/alerts/" + created.Data.ID + "/close", http.StatusOK},
+		{"/opsgenie/v1/alerts/" + created.Data.ID + "/acknowledge", http.StatusConflict},
+		{"/opsgenie/v1/alerts/opsgenie-42/acknowledge", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("POST %s: status %d, want %d", tt.path, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestPagerDutyEnqueue(t *testing.T) {
+	srv := NewServer()
+
+	valid := `{"routing_key":"key","event_action":"trigger","dedup_key":"disk-full",` +
+		`"payload":{"summary":"Disk full","source":"db-1","severity":"critical"}}`
+	rec := serve(srv, http.MethodPost, "/v2/enqueue", valid, nil)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("trigger: status %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("trigger: invalid response: %v", err)
+	}
+	if resp["status"] != "success" || resp["message"] == "" || resp["dedup_key"] != "disk-full" {
+		t.Errorf("trigger: response %v, want status, message and dedup_key", resp)
+	}
+
+	invalid := []struct {
+		name, body, wantErr string
+	}{
+		{"missing routing_key", `{"event_action":"trigger","payload":{"summary":"s","source":"h","severity":"info"}}`, "routing_key"},
+		{"missing event_action", `{"routing_key":"key","payload":{"summary":"s","source":"h","severity":"info"}}`, "event_action"},
+		{"missing payload.summary", `{"routing_key":"key","event_action":"trigger","payload":{"source":"h","severity":"info"}}`, "payload.summary"},
+		{"missing payload.source", `{"routing_key":"key","event_action":"trigger","payload":{"summary":"s","severity":"info"}}`, "payload.source"},
+		{"missing payload.severity", `{"routing_key":"key","event_action":"trigger","payload":{"summary":"s","source":"h"}}`, "payload.severity"},
+		{"missing dedup_key", `{"routing_key":"key","event_action":"resolve"}`, "dedup_key"},
+		{"invalid JSON", `{"routing_key":`, "Invalid JSON"},
+	}
+
+	for _, tt := range invalid {
+		rec := serve(srv, http.MethodPost, "/v2/enqueue", tt.body, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, http.StatusBadRequest)
+			continue
+		}
+		var resp struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0], tt.wantErr) {
+			t.Errorf("%s: errors %q, want one about %s", tt.name, resp.Errors, tt.wantErr)
+		}
+	}
+
+	transitions := []struct {
+		name, action, key, wantStatus string
+	}{
+		{"acknowledge", "acknowledge", "disk-full", StatusAcknowledged},
+		{"unknown key", "resolve", "unknown", StatusAcknowledged},
+		{"resolve", "resolve", "disk-full", StatusResolved},
+	}
+
+	for _, tt := range transitions {
+		body := `{"routing_key":"key","event_action":"` + tt.action + `","dedup_key":"` + tt.key + `"}`
+		rec := serve(srv, http.MethodPost, "/v2/enqueue", body, nil)
+		// Like the real API, events for unknown incidents are accepted and dropped
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, http.StatusAccepted)
+		}
+
+		incidents := srv.store.List("pagerduty")
+		if len(incidents) != 1 || incidents[0].Status != tt.wantStatus {
+			t.Errorf("%s: incidents %+v, want one %s incident", tt.name, incidents, tt.wantStatus)
+		}
+	}
+}
+
+func TestOpsGenieAlertV2(t *testing.T) {
+	srv := NewServer()
+	auth := http.Header{"Authorization": {"GenieKey test-key"}}
+	alert := `{"message":"Disk full","alias":"disk-full","priority":"P1"}`
+
+	unauthorized := []http.Header{
+		nil,
+		{"Authorization": {"Bearer test-key"}},
+		{"Authorization": {"GenieKey  "}},
+	}
+	for _, header := range unauthorized {
+		if rec := serve(srv, http.MethodPost, "/v2/alerts", alert, header); rec.Code != http.StatusUnauthorized {
+			t.Errorf("create with %v: status %d, want %d", header, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	if rec := serve(srv, http.MethodGet, "/v2/alerts/requests/unknown", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("request status without key: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	rec := serve(srv, http.MethodPost, "/v2/alerts", alert, auth)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("create: status %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	var accepted struct {
+		Result    string   `json:"result"`
+		Took      *float64 `json:"took"`
+		RequestID string   `json:"requestId"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&accepted); err != nil {
+		t.Fatalf("create: invalid response: %v", err)
+	}
+	if accepted.Result == "" || accepted.Took == nil || accepted.RequestID == "" {
+		t.Fatalf("create: response %+v, want result, took and requestId", accepted)
+	}
+
+	status := pollRequest(t, srv, accepted.RequestID, auth)
+	incidents := srv.store.List("opsgenie")
+	if !status.IsSuccess || len(incidents) != 1 || status.AlertID != incidents[0].ID || status.Alias != "disk-full" {
+		t.Errorf("create: request status %+v, want success for alert %+v", status, incidents)
+	}
+
+	rec = serve(srv, http.MethodPost, "/v2/alerts/disk-full/close?identifierType=alias", "{}", auth)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("close: status %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	json.NewDecoder(rec.Body).Decode(&accepted)
+	if status := pollRequest(t, srv, accepted.RequestID, auth); !status.IsSuccess || status.Action != "Close" {
+		t.Errorf("close: request status %+v, want a successful close", status)
+	}
+
+	if rec := serve(srv, http.MethodGet, "/v2/alerts/requests/unknown", "", auth); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown request: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestRequestTrackerEviction(t *testing.T) {
+	tracker := newRequestTracker()
+	tracker.limit = 3
+
+	for _, id := range []string{"a", "b", "c", "b", "d"} {
+		tracker.record(id, opsGenieRequest{Action: id})
+	}
+
+	tests := []struct {
+		id   string
+		kept bool
+	}{
+		{"a", false},
+		{"b", true},
+		{"c", true},
+		{"d", true},
+	}
+	for _, tt := range tests {
+		if _, ok := tracker.get(tt.id); ok != tt.kept {
+			t.Errorf("request %s kept: %v, want %v", tt.id, ok, tt.kept)
+		}
+	}
+	if len(tracker.requests) != 3 || len(tracker.order) != 3 {
+		t.Errorf("tracker holds %d requests in order %v, want 3", len(tracker.requests), tracker.order)
+	}
+}
+
+// pollRequest polls the status of an OpsGenie request until it is processed
+func pollRequest(t *testing.T, srv *Server, id string, auth http.Header) opsGenieRequest {
+	t.Helper()
+
+	for range 10 {
+		rec := serve(srv, http.MethodGet, "/v2/alerts/requests/"+id, "", auth)
+		if rec.Code == http.StatusNotFound {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request status: status %d, want %d", rec.Code, http.StatusOK)
+		}
+
+		var resp struct {
+			Data opsGenieRequest `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("request status: invalid response: %v", err)
+		}
+		return resp.Data
+	}
+
+	t.Fatalf("request %s was never processed", id)
+	return opsGenieRequest{}
+}
+
+// serve sends a request to the server and returns the recorded response
+func serve(srv *Server, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+// trigger returns a step triggering an incident with a dedup key
+func trigger(s *Store, provider, key string) func() (Incident, bool, error) {
+	return func() (Incident, bool, error) {
+		inc, merged := s.Trigger(Incident{Provider: provider, DedupKey: key, Message: "Disk full"})
+		return inc, merged, nil
+	}
+}
+
+// transition returns a step moving an opsgenie incident to a new status
+func transition(s *Store, id, status string) func() (Incident, bool, error) {
+	return func() (Incident, bool, error) {
+		inc, err := s.Transition("opsgenie", id, status)
+		return inc, false, err
+	}
+}
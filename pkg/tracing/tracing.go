@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/copydataai/fake-backend-alerts/pkg/provider"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span exporters supported by Setup
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// tracer creates the spans of alert sends
+var tracer = otel.Tracer("github.com/copydataai/fake-backend-alerts/pkg/tracing")
+
+// Setup installs a global tracer provider exporting spans with the given exporter, and
+// propagates the W3C trace context on provider requests. endpoint is the URL of the OTLP
+// HTTP collector, which defaults to the OTEL_EXPORTER_OTLP_ENDPOINT environment variable
+// or http://localhost:4318. The returned function flushes the remaining spans.
+func Setup(ctx context.Context, exporter, endpoint string) (shutdown func(context.Context) error, err error) {
+	var exp sdktrace.SpanExporter
+	switch exporter {
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			u, err := url.Parse(endpoint)
+			if err != nil {
+				return nil, fmt.Errorf("invalid OTLP endpoint: %v", err)
+			}
+			// Like the environment variable, the endpoint is the base URL of the collector
+			u.Path = strings.TrimSuffix(u.Path, "/") + "/v1/traces"
+			opts = append(opts, otlptracehttp.WithEndpointURL(u.String()))
+		}
+		exp, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		// Spans go to stderr, which keeps stdout clean for reports
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %v", exporter, err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "alertcli"))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
+
+// Wrap returns a provider that traces the alerts sent through p by a scenario
+func Wrap(p provider.Provider, scenario string) provider.Provider {
+	return &tracedProvider{Provider: p, scenario: scenario}
+}
+
+// tracedProvider is a provider creating a span for every alert it sends
+type tracedProvider struct {
+	provider.Provider
+	scenario string
+}
+
+// SendAlert sends an alert through the wrapped provider in a span recording the
+// outcome of its HTTP requests
+func (p *tracedProvider) SendAlert(ctx context.Context, alert provider.Alert) error {
+	ctx, span := tracer.Start(ctx, "SendAlert",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("alertcli.scenario", p.scenario),
+			attribute.String("alert.id", alert.ID),
+			attribute.String("alert.provider", p.Name()),
+			attribute.String("alert.severity", alert.Severity),
+		),
+	)
+	defer span.End()
+
+	// Reuse the request info of the caller, if any, so it is still filled
+	info := provider.RequestInfoFrom(ctx)
+	if info == nil {
+		info = &provider.RequestInfo{}
+		ctx = provider.WithRequestInfo(ctx, info)
+	}
+	attempts := info.Attempts
+
+	err := p.Provider.SendAlert(ctx, alert)
+
+	if info.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", info.StatusCode))
+	}
+	span.SetAttributes(attribute.Int("alertcli.retries", max(info.Attempts-attempts-1, 0)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}